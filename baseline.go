@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const baselineFileName = ".testcov-baseline.json"
+
+// Baseline is the number of untested sections per file, to onboard untested code without adding comments
+type Baseline struct {
+	path     string         // "" when there is no baseline
+	untested map[string]int // by path relative to the working directory
+	lines    map[string]int // where each path is configured, so we can point users to it
+}
+
+// read the baseline if it exists
+func readBaseline(path string) (baseline Baseline, err error) {
+	if _, err := os.Stat(path); err != nil {
+		return Baseline{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Baseline{}, err
+	}
+	content := string(data)
+	baseline = Baseline{path: path, untested: map[string]int{}, lines: map[string]int{}}
+	if err := json.Unmarshal([]byte(content), &baseline.untested); err != nil {
+		return Baseline{}, fmt.Errorf("%v: %v", path, err)
+	}
+
+	for key := range baseline.untested {
+		quoted, _ := json.Marshal(key)
+		if index := strings.Index(content, string(quoted)+":"); index != -1 {
+			baseline.lines[key] = strings.Count(content[:index], "\n") + 1
+		}
+	}
+	return
+}
+
+// how many sections are expected to be untested for a file and on what line that is configured
+func (b Baseline) untestedForFile(readPath string, workingDirectory string) (count int, line int, found bool) {
+	key := baselineKey(readPath, workingDirectory)
+	count, found = b.untested[key]
+	return count, b.lines[key], found
+}
+
+// write the current untested count of every file that is not configured otherwise
+func writeBaseline(path string, report Report, workingDirectory string) error {
+	untested := map[string]int{}
+	for _, file := range report.Files {
+		if file.ConfiguredIn == "" && file.ActualUntested > 0 {
+			untested[baselineKey(file.ReadPath, workingDirectory)] = file.ActualUntested
+		}
+	}
+
+	content, _ := json.MarshalIndent(untested, "", "  ") // sorted with one file per line, so diffs are readable, counts always marshal
+	if err := os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return ConfigError{fmt.Errorf("baseline could not be written: %w", err)}
+	}
+
+	_, _ = fmt.Fprintf(os.Stderr, "go-testcov: wrote %v files with untested sections to %v\n", len(untested), path)
+	return nil
+}
+
+func baselineKey(readPath string, workingDirectory string) string {
+	return filepath.ToSlash(relativeToWorkingDirectory(readPath, workingDirectory))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ANSI escape codes for the colors we use
+const colorRed = "\033[31m"
+const colorYellow = "\033[33m"
+const colorDim = "\033[2m"
+const colorReset = "\033[0m"
+
+// test injection point to enable test coverage of tty behavior
+// terminals are character devices, but so is the /dev/null that output is often discarded to
+var isTerminal = func(file *os.File) bool {
+	info, err := file.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
+
+// color when a human is watching, unless they opted out with --no-color or NO_COLOR https://no-color.org
+func useColor(noColor bool) bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stderr)
+}
+
+func colorize(color bool, code string, text string) string {
+	if !color {
+		return text
+	}
+	return code + text + colorReset
+}
+
+// source lines of the section with the untested characters highlighted, columns are 1-based bytes like in the profile
+func sourceContext(content string, section Section) (context string) {
+	lines := strings.Split(content, "\n")
+	for number := section.startLine; number <= section.endLine && number <= len(lines); number++ {
+		line := lines[number-1]
+		start, end := 0, len(line)
+		if number == section.endLine && section.endChar-1 < end {
+			end = section.endChar - 1
+		}
+		if number == section.startLine {
+			start = section.startChar - 1
+		}
+		if start > end {
+			start = end // the file changed since the profile was written
+		}
+		context += fmt.Sprintf(
+			"%v %v%v%v\n",
+			colorize(true, colorDim, fmt.Sprintf("%5d |", number)), line[:start], colorize(true, colorRed, line[start:end]), line[end:])
+	}
+	return
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const configFileName = ".go-testcov.yml"
+
+// Config is read from .go-testcov.yml, to configure files we cannot (or do not want to) add comments to
+type Config struct {
+	path      string   // where the config was found, "" when there is none
+	format    string   // default --format
+	flags     []string // default `go test` flags
+	generated []string // globs of generated files to skip, in addition to generated.go and `// Code generated` headers
+	untested  []ConfigUntested
+}
+
+// ConfigUntested is what is expected to be untested for files matching the glob, like the per-file comment
+type ConfigUntested struct {
+	glob    string
+	count   int
+	percent bool
+	line    int
+}
+
+// find the config file in the working directory or its parents, stopping at the module root
+func findConfig(workingDirectory string) (config Config, err error) {
+	dir := workingDirectory
+	for {
+		path := filepath.Join(dir, configFileName)
+		if _, err := os.Stat(path); err == nil {
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return Config{}, err
+			}
+			return parseConfig(path, string(content))
+		}
+
+		// do not leave the module
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return Config{}, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return Config{}, nil
+		}
+		dir = parent
+	}
+}
+
+// parse the subset of yaml we support: top-level keys with scalar values, lists, or maps
+//
+//	format: json
+//	flags: -race -covermode atomic
+//	generated:
+//	  - "*.pb.go"
+//	untested:
+//	  "vendor/**": ignore
+//	  legacy/*.go: 5
+func parseConfig(path string, content string) (config Config, err error) {
+	config.path = path
+	key := "" // top-level key that indented lines belong to
+
+	for i, raw := range strings.Split(content, "\n") {
+		lineNumber := i + 1
+		line := removeYamlComment(raw)
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fail := func(message string, args ...interface{}) (Config, error) {
+			return Config{}, fmt.Errorf("%v:%v: %v", path, lineNumber, fmt.Sprintf(message, args...))
+		}
+
+		// top-level key
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			name, value, found := strings.Cut(line, ":")
+			if !found {
+				return fail("expected `key: value`")
+			}
+			key = strings.TrimSpace(name)
+			value = unquoteYaml(strings.TrimSpace(value))
+
+			switch key {
+			case "format":
+				if !slices.Contains(formats, value) {
+					return fail("unknown format %q, use one of %v", value, strings.Join(formats, ", "))
+				}
+				config.format = value
+			case "flags":
+				config.flags = strings.Fields(value)
+			case "generated", "untested":
+				if value != "" {
+					return fail("%v needs to be a nested list", key)
+				}
+			default:
+				return fail("unknown key %q", key)
+			}
+			continue
+		}
+
+		// nested list items or map entries
+		line = strings.TrimSpace(line)
+		switch key {
+		case "flags", "generated":
+			item, found := strings.CutPrefix(line, "- ")
+			if !found {
+				return fail("expected `- value`")
+			}
+			if key == "flags" {
+				config.flags = append(config.flags, unquoteYaml(strings.TrimSpace(item)))
+			} else {
+				config.generated = append(config.generated, unquoteYaml(strings.TrimSpace(item)))
+			}
+		case "untested":
+			colon := strings.LastIndex(line, ":") // globs might contain `:` but values never do
+			if colon == -1 {
+				return fail("expected `glob: count`")
+			}
+			glob := unquoteYaml(strings.TrimSpace(line[:colon]))
+			count, percent, parseErr := parseConfiguredUntested(unquoteYaml(strings.TrimSpace(line[colon+1:])))
+			if parseErr != nil {
+				return fail("%v", parseErr)
+			}
+			config.untested = append(config.untested, ConfigUntested{glob, count, percent, lineNumber})
+		default:
+			return fail("unexpected indentation")
+		}
+	}
+	return
+}
+
+// remove `# comment` unless it is inside of quotes
+func removeYamlComment(line string) string {
+	quote := rune(0)
+	for i, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquoteYaml(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// path of the file relative to the config, which is what globs match against
+// paths that cannot be made relative stay as they are, like other files outside of the config directory
+func (c Config) relativePath(path string) string {
+	absolute, err := filepath.Abs(path)
+	relative := ""
+	if err == nil {
+		relative, err = filepath.Rel(filepath.Dir(c.path), absolute)
+	}
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(relative)
+}
+
+// is the file generated according to the config ?
+func (c Config) isGenerated(path string) bool {
+	if c.path == "" {
+		return false
+	}
+	relative := c.relativePath(path)
+	for _, glob := range c.generated {
+		if matchGlob(glob, relative) {
+			return true
+		}
+	}
+	return false
+}
+
+// first configured untested that matches the file, same semantics as configuredUntestedForFile
+func (c Config) untestedForFile(path string) (untested ConfigUntested, found bool) {
+	if c.path == "" {
+		return
+	}
+	relative := c.relativePath(path)
+	for _, untested := range c.untested {
+		if matchGlob(untested.glob, relative) {
+			return untested, true
+		}
+	}
+	return
+}
+
+// match a slash separated path against a glob where `*` does not cross directories and `**` does
+func matchGlob(glob string, path string) bool {
+	var pattern strings.Builder
+	pattern.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch {
+		case strings.HasPrefix(glob[i:], "**/"):
+			pattern.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			pattern.WriteString(".*")
+			i++
+		case glob[i] == '*':
+			pattern.WriteString("[^/]*")
+		case glob[i] == '?':
+			pattern.WriteString("[^/]")
+		default:
+			pattern.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		}
+	}
+	pattern.WriteString("$")
+	return regexp.MustCompile(pattern.String()).MatchString(path)
+}
+
+// parse "x", "x%" or "ignore"
+func parseConfiguredUntested(config string) (count int, percent bool, err error) {
+	if config == "ignore" {
+		return 100, true, nil // 100% which does not warn for any amount, so basically ignored
+	}
+
+	number, percent := strings.CutSuffix(config, "%")
+	count, err = strconv.Atoi(number)
+	if err != nil {
+		return 0, false, fmt.Errorf("expected a count, percentage or ignore but got %q", config)
+	}
+	return count, percent, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// reused regex
+var diffFileHeader = regexp.MustCompile(`^\+\+\+ (?:b/)?(.+)$`)
+var diffHunkHeader = regexp.MustCompile(`^@@ -\S+ \+(\d+)(?:,(\d+))? @@`)
+
+// LineRange is an inclusive range of changed lines in a file
+type LineRange struct {
+	start int
+	end   int
+}
+
+// fail before running tests when the ref does not exist, so a typo does not cost a whole test run
+func verifyRef(ref string) error {
+	if _, err := runGit("rev-parse", "--verify", ref+"^{commit}"); err != nil {
+		return ConfigError{fmt.Errorf("--diff=%v is not a commit: %v", ref, err)}
+	}
+	return nil
+}
+
+// changed lines per file path relative to the working directory, compared to the given git ref
+func changedLines(ref string) (map[string][]LineRange, error) {
+	// --relative makes paths match what we read from the coverage file
+	output, err := runGit("diff", "--relative", "--unified=0", "--no-color", "--no-ext-diff", ref)
+	if err != nil {
+		return nil, ConfigError{fmt.Errorf("--diff=%v could not be compared: %v", ref, err)}
+	}
+	changed, err := parseDiff(output)
+	if err != nil {
+		return nil, fmt.Errorf("--diff=%v could not be parsed: %w", ref, err)
+	}
+	return changed, nil
+}
+
+// output of a git command, errors are what git printed
+func runGit(args ...string) (string, error) {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok && len(exitError.Stderr) != 0 {
+			return "", errors.New(strings.TrimSpace(string(exitError.Stderr)))
+		}
+		return "", err
+	}
+	return string(output), nil
+}
+
+// parse `git diff --unified=0` output into the added/modified line ranges of each file
+func parseDiff(diff string) (changed map[string][]LineRange, err error) {
+	changed = map[string][]LineRange{}
+	path := ""
+	previous := ""
+	for _, line := range splitWithoutEmpty(diff, '\n') {
+		// file headers come in pairs, so added lines that look like a header are not confused with it
+		if match := diffFileHeader.FindStringSubmatch(line); match != nil && strings.HasPrefix(previous, "--- ") {
+			path = match[1] // "/dev/null" for deleted files, which never have coverage
+		} else if match := diffHunkHeader.FindStringSubmatch(line); match != nil {
+			start, err := strconv.Atoi(match[1])
+			count := 1
+			if err == nil && match[2] != "" {
+				count, err = strconv.Atoi(match[2])
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid hunk header %q: %w", line, err)
+			}
+			if count != 0 { // 0 means lines were only removed
+				changed[path] = append(changed[path], LineRange{start, start + count - 1})
+			}
+		}
+		previous = line
+	}
+	return
+}
+
+// find the changed lines of a file we read coverage for, which might be absolute when in GOPATH
+func changedLinesForFile(changed map[string][]LineRange, readPath string, workingDirectory string) []LineRange {
+	return changed[filepath.ToSlash(relativeToWorkingDirectory(readPath, workingDirectory))]
+}
+
+// keep sections that overlap any of the changed lines
+func sectionsInChangedLines(sections []Section, changed []LineRange) (kept []Section) {
+	kept = []Section{}
+	for _, section := range sections {
+		for _, lines := range changed {
+			if section.startLine <= lines.end && lines.start <= section.endLine {
+				kept = append(kept, section)
+				break
+			}
+		}
+	}
+	return
+}
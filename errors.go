@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// exit codes for CI scripts, when tests fail the exit code of the test command is passed through
+const exitCoverage = 3 // new untested sections, or below --min-total
+const exitConfig = 4   // invalid options, .go-testcov.yml, baseline, `untested sections` comment or --diff ref
+const exitProfile = 5  // coverage profile or covered file could not be read, or go-testcov itself failed
+
+// ConfigError is something the user needs to fix in how go-testcov is configured
+type ConfigError struct {
+	err error
+}
+
+func (e ConfigError) Error() string {
+	return e.err.Error()
+}
+
+func (e ConfigError) Unwrap() error {
+	return e.err
+}
+
+// ProfileError is a coverage profile, or a file it covers, that could not be read
+type ProfileError struct {
+	path string // "" when the error already says which file it is about
+	line int    // 0 when not about a line
+	err  error
+}
+
+func (e ProfileError) Error() string {
+	switch {
+	case e.path == "":
+		return e.err.Error()
+	case e.line == 0:
+		return fmt.Sprintf("%v: %v", e.path, e.err)
+	default:
+		return fmt.Sprintf("%v:%v: %v", e.path, e.line, e.err)
+	}
+}
+
+func (e ProfileError) Unwrap() error {
+	return e.err
+}
+
+// tell the user what went wrong without a stack trace
+func exitWithError(err error) (exitCode int) {
+	_, _ = fmt.Fprintf(os.Stderr, "go-testcov: %v\n", err)
+	if errors.As(err, &ConfigError{}) {
+		return exitConfig
+	}
+	return exitProfile
+}
+
+// turn unexpected panics into an error message, they are bugs in go-testcov or the environment
+func recoverAsError(exitCode *int) {
+	if recovered := recover(); recovered != nil {
+		*exitCode = exitWithError(fmt.Errorf("internal error: %v", recovered))
+	}
+}
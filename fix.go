@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// rewrite the configured untested comment to the actual count and remove inline ignores on tested code
+// returns the report as if the file had been fixed before checking
+func fixFile(file FileReport, content string) (FileReport, error) {
+	lines := strings.Split(content, "\n")
+	removals := map[int]int{} // line number -> where the comment to remove starts
+	replacements := map[int]string{}
+
+	kept := []Warning{}
+	for _, warning := range file.Warnings {
+		if warning.Rule != ruleTestedIgnore {
+			kept = append(kept, warning)
+			continue
+		}
+		line := lines[warning.Line-1]
+		removals[warning.Line] = strings.LastIndex(line[:strings.LastIndex(line, "untested section")], "//")
+		printFix(file, warning.Line, "removed tested `// untested section`")
+	}
+
+	if file.Status == statusDecrement && file.ConfiguredIn == file.ReadPath {
+		line := lines[file.ConfiguredAtLine-1]
+		match := perFileIgnore.FindStringSubmatchIndex(line)
+		if file.ActualUntested == 0 {
+			removals[file.ConfiguredAtLine] = match[0]
+			printFix(file, file.ConfiguredAtLine, "removed `// untested sections`")
+		} else {
+			replacements[file.ConfiguredAtLine] = line[:match[2]] + strconv.Itoa(file.ActualUntested) + line[match[3]:] // only replace the count
+			printFix(file, file.ConfiguredAtLine, fmt.Sprintf("decremented `// untested sections` to %v", file.ActualUntested))
+		}
+		file.Status = statusPass
+		file.ConfiguredUntested = file.ActualUntested
+	}
+
+	if len(removals) == 0 && len(replacements) == 0 {
+		return file, nil
+	}
+
+	for lineNumber, replacement := range replacements {
+		lines[lineNumber-1] = replacement
+	}
+
+	// remove from the bottom up so line numbers stay valid
+	lineNumbers := []int{}
+	for lineNumber := range removals {
+		lineNumbers = append(lineNumbers, lineNumber)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(lineNumbers)))
+	for _, lineNumber := range lineNumbers {
+		code := strings.TrimRight(lines[lineNumber-1][:removals[lineNumber]], " \t")
+		if strings.TrimSpace(code) == "" {
+			lines = append(lines[:lineNumber-1], lines[lineNumber:]...) // comment was the whole line
+		} else {
+			lines[lineNumber-1] = code
+		}
+	}
+
+	// the file exists, so its permissions are kept
+	if err := os.WriteFile(file.ReadPath, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+		return file, ProfileError{path: file.DisplayPath, err: fmt.Errorf("covered file could not be fixed: %w", err)}
+	}
+
+	file.Warnings = kept
+	return file, nil
+}
+
+func printFix(file FileReport, line int, message string) {
+	_, _ = fmt.Fprintf(os.Stderr, "go-testcov (fix): %v:%v %v\n", file.DisplayPath, line, message)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+)
+
+// FunctionReport is how much of a function or method is untested, so users know where to focus
+type FunctionReport struct {
+	Name            string `json:"name"` // like `handleLogin` or `(*Server).handleLogin`
+	Line            int    `json:"line"`
+	Untested        int    `json:"untested"`
+	Total           int    `json:"total"`
+	PercentUntested int    `json:"percentUntested"`
+}
+
+// attribute untested sections to the function or method they are in, including the func literals inside it
+// files that do not parse are skipped since coverage only needs lines
+func functionReports(path string, content string, sections []Section, untested []Section, unit string) (functions []FunctionReport) {
+	fileSet := token.NewFileSet()
+	parsed, err := parser.ParseFile(fileSet, path, content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	for _, decl := range parsed.Decls {
+		function, ok := decl.(*ast.FuncDecl)
+		if !ok || function.Body == nil {
+			continue
+		}
+		start, end := fileSet.Position(function.Pos()), fileSet.Position(function.End())
+		report := FunctionReport{
+			Name:     functionName(function),
+			Line:     start.Line,
+			Untested: countIn(sectionsBetween(untested, start, end), unit),
+			Total:    countIn(sectionsBetween(sections, start, end), unit),
+		}
+		if report.Untested != 0 {
+			report.PercentUntested = percent(report.Untested, report.Total)
+			functions = append(functions, report)
+		}
+	}
+
+	// most untested first
+	sort.SliceStable(functions, func(i, j int) bool {
+		return functions[i].Untested > functions[j].Untested
+	})
+	return
+}
+
+// name like go doc shows it, methods are prefixed with their receiver type
+func functionName(function *ast.FuncDecl) string {
+	if function.Recv == nil || len(function.Recv.List) == 0 {
+		return function.Name.Name
+	}
+	return "(" + types.ExprString(function.Recv.List[0].Type) + ")." + function.Name.Name
+}
+
+// sections that start inside of the range
+func sectionsBetween(sections []Section, start token.Position, end token.Position) (between []Section) {
+	for _, section := range sections {
+		afterStart := section.startLine > start.Line || (section.startLine == start.Line && section.startChar >= start.Column)
+		beforeEnd := section.startLine < end.Line || (section.startLine == end.Line && section.startChar <= end.Column)
+		if afterStart && beforeEnd {
+			between = append(between, section)
+		}
+	}
+	return
+}
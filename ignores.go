@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// kinds of ignore markers
+const ignoreFile = "file"         // budget for the whole file at the top of it
+const ignoreSection = "section"   // inline on or above code
+const ignoreRandom = "random"     // inline, but not warned about when tested
+const ignoreBlock = "block"       // everything until the closing brace
+const ignoreFunction = "function" // directive in the doc comment of a function
+
+// Ignore is a marker in the code that excuses untested code
+type Ignore struct {
+	Line       int    `json:"line"`
+	Kind       string `json:"kind"`
+	Reason     string `json:"reason"`          // "" when none was given
+	Until      string `json:"until,omitempty"` // date after which it no longer applies
+	Expired    bool   `json:"expired"`
+	Suppressed int    `json:"suppressed"` // untested sections or statements it excuses
+	Stale      bool   `json:"stale"`      // excuses nothing, so it can be removed
+}
+
+// markers in the order they need to be checked, since a random marker is also a section marker
+var ignoreMarkers = []struct {
+	kind  string
+	regex *regexp.Regexp
+}{
+	{ignoreFile, perFileIgnore},
+	{ignoreRandom, randomInlineIgnore},
+	{ignoreSection, anyInlineIgnore},
+	{ignoreBlock, blockIgnore},
+	{ignoreFunction, ignoreDirective},
+}
+
+// every marker in the file with the reason written after it, separated by a space, colon or comma
+func findIgnores(path string, content string) (ignores []Ignore, err error) {
+	for _, comment := range commentLines(path, content) {
+		line := strings.TrimSpace(comment.text)
+		for _, marker := range ignoreMarkers {
+			if location := marker.regex.FindStringIndex(line); location != nil {
+				ignore := Ignore{Line: comment.line, Kind: marker.kind}
+				if ignore.Until, ignore.Expired, err = markerExpiry(line[location[1]:]); err != nil {
+					return nil, ConfigError{fmt.Errorf("%v:%v: %v", path, ignore.Line, err)}
+				}
+				ignore.Reason = strings.Trim(untilDate.ReplaceAllString(line[location[1]:], ""), " \t:,-")
+				ignores = append(ignores, ignore)
+				break
+			}
+		}
+	}
+	return
+}
+
+// commentLine is text that can contain a marker
+type commentLine struct {
+	line int
+	text string
+}
+
+// comments of go files, so strings that mention a marker are not mistaken for one, every line of files that do not parse
+func commentLines(path string, content string) (comments []commentLine) {
+	fileSet := token.NewFileSet()
+	parsed, err := parser.ParseFile(fileSet, path, content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		for i, line := range strings.Split(content, "\n") {
+			comments = append(comments, commentLine{i + 1, line})
+		}
+		return
+	}
+	for _, group := range parsed.Comments {
+		for _, comment := range group.List {
+			comments = append(comments, commentLine{fileSet.Position(comment.Pos()).Line, comment.Text})
+		}
+	}
+	return
+}
+
+// markers can expire with `until 2026-12-31` or `until=2026-12-31`, they apply until the end of that day
+func markerExpiry(text string) (until string, expired bool, err error) {
+	match := untilDate.FindStringSubmatch(text)
+	if match == nil {
+		return "", false, nil
+	}
+	if _, err := time.Parse(time.DateOnly, match[1]); err != nil {
+		return "", false, fmt.Errorf("expected a date like until=2026-12-31 but got %q", match[1])
+	}
+	return match[1], now().Format(time.DateOnly) > match[1], nil
+}
+
+func isExpired(text string) bool {
+	_, expired, _ := markerExpiry(text)
+	return expired
+}
+
+// how much untested code each marker excuses, kept are the untested sections that no marker excused
+func countSuppressed(file FileReport, content string, sections []Section, untested []Section, kept []Section) []Ignore {
+	excused := []Section{}
+	for _, section := range untested {
+		if !slices.Contains(kept, section) {
+			excused = append(excused, section)
+		}
+	}
+
+	ignores := file.Ignores
+	scopesByLine := markerScopes(file.ReadPath, content)
+	lines := strings.Split(content, "\n")
+	for i := range ignores {
+		ignore := &ignores[i]
+		switch {
+		case ignore.Kind == ignoreFile:
+			ignore.Suppressed, ignore.Stale = budgetSuppressed(file, ignore.Line, countIn(kept, file.Unit))
+			continue
+		case scopesByLine != nil:
+			ignore.Suppressed = countIn(excused, file.Unit) - countIn(outsideScopes(excused, sections, scopesByLine[ignore.Line]), file.Unit)
+		default:
+			for _, section := range excused {
+				if markerOfSection(ignores, lines, section) == i {
+					ignore.Suppressed += countIn([]Section{section}, file.Unit)
+				}
+			}
+		}
+		ignore.Stale = ignore.Kind != ignoreRandom && ignore.Suppressed == 0 // random markers are expected to be tested sometimes
+	}
+	return ignores
+}
+
+// markers that apply to no code and inline markers that excuse nothing,
+// using the same scopes as removeIgnoredSections so warnings agree with the ignores table
+// files that do not parse fall back to the line rules of findCoveredInlineIgnores
+func findIgnoreWarnings(file FileReport, content string, sections []Section) (warnings []Warning) {
+	lines := strings.Split(content, "\n")
+	scopesByLine := markerScopes(file.ReadPath, content)
+	if scopesByLine == nil {
+		return findCoveredInlineIgnores(sections, lines)
+	}
+
+	warnings = []Warning{}
+	for _, ignore := range file.Ignores {
+		scopes, inComment := scopesByLine[ignore.Line]
+		switch {
+		case inComment && len(scopes) == 0:
+			warnings = append(warnings, Warning{ignore.Line, "has an ignore marker that is not on or above code, so it does not apply", ruleMisplacedIgnore})
+		case ignore.Kind != ignoreSection || !ignore.Stale || !inComment:
+			// not an inline marker on tested code
+		case startsWithInlineIgnore.MatchString(lines[ignore.Line-1]):
+			warnings = append(warnings, Warning{ignore.Line, "has `// untested section` but the code below is tested", ruleTestedIgnore})
+		default:
+			warnings = append(warnings, Warning{ignore.Line, "has `// untested section` but is tested", ruleTestedIgnore})
+		}
+	}
+	return
+}
+
+// a file budget excuses untested code up to what is configured, when it is what configures the file
+func budgetSuppressed(file FileReport, line int, actual int) (suppressed int, stale bool) {
+	if file.ConfiguredIn != file.ReadPath || file.ConfiguredAtLine != line {
+		return 0, true
+	}
+	if file.Percent {
+		return actual, actual == 0
+	}
+	if actual > file.ConfiguredUntested {
+		return file.ConfiguredUntested, false
+	}
+	return actual, actual < file.ConfiguredUntested
+}
+
+// without syntax tree, use the same line rules as removeSectionsMarkedWithInlineComment:
+// a marker on a line of the section or on its own line above it, otherwise the closest block marker above
+func markerOfSection(ignores []Ignore, lines []string, section Section) (index int) {
+	index = -1
+	for i, ignore := range ignores {
+		switch {
+		case ignore.Kind == ignoreFile || ignore.Kind == ignoreFunction || ignore.Expired:
+			// need the syntax tree or do not apply
+		case ignore.Kind == ignoreBlock:
+			if ignore.Line < section.startLine {
+				index = i
+			}
+		case section.startLine <= ignore.Line && ignore.Line <= section.endLine,
+			ignore.Line == section.startLine-1 && strings.HasPrefix(strings.TrimSpace(lines[ignore.Line-1]), "//"):
+			return i
+		}
+	}
+	return
+}
+
+// markers without a reason, which --strict does not allow, all of them so they can be fixed in one go
+func findMissingReasons(ignores []Ignore) (warnings []Warning) {
+	warnings = []Warning{}
+	for _, ignore := range ignores {
+		if ignore.Reason == "" {
+			warnings = append(warnings, Warning{ignore.Line, fmt.Sprintf("has a %v marker without reason, --strict needs it after the marker like `: why it is untested`", ignore.Kind), ruleMissingReason})
+		}
+	}
+	return
+}
+
+// table of all markers, so reviewers can audit why code is untested and remove stale markers
+func writeIgnores(out io.Writer, report Report) {
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(table, "LOCATION\tKIND\tREASON\tSUPPRESSED\tSTALE")
+	for _, file := range report.Files {
+		for _, ignore := range file.Ignores {
+			reason := ignore.Reason
+			if reason == "" {
+				reason = "-"
+			}
+			stale := "no"
+			if ignore.Stale {
+				stale = "yes"
+			}
+			_, _ = fmt.Fprintf(table, "%v:%v\t%v\t%v\t%v %v\t%v\n", file.DisplayPath, ignore.Line, ignore.Kind, reason, ignore.Suppressed, file.Unit, stale)
+		}
+	}
+	check(table.Flush())
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"sort"
 	"strings"
+	"time"
 )
 
 const version = "v1.14.0"
@@ -18,121 +20,409 @@ var anyInlineIgnore = regexp.MustCompile(inlineIgnore)
 var startsWithInlineIgnore = regexp.MustCompile("^\\s*" + inlineIgnore)
 var randomInlineIgnore = regexp.MustCompile(`//.*untested section\s+random(\s|:|,|$)`)
 var blockIgnore = regexp.MustCompile("(?m)^([\t ]*)// *untested block(\\s|:|,|$)")
+var ignoreDirective = regexp.MustCompile(`^//testcov:ignore(\s|:|,|$)`)
 var perFileIgnore = regexp.MustCompile("// *untested sections: *(\\S+)")
 var generatedFile = regexp.MustCompile("/*generated.*\\.go$")
+var generatedHeader = regexp.MustCompile(`^// Code generated .* DO NOT EDIT\.$`)
+var packageClause = regexp.MustCompile(`^package\s`)
+var untilDate = regexp.MustCompile(`\s*\buntil[ =](\d{4}-\d{2}-\d{2})`)
 
 // test injection point to enable test coverage of exit behavior
 var exitFunction = os.Exit
 
+// test injection point to enable test coverage of expiring markers
+var now = time.Now
+
 // delegate to runGoTestAndCheckCoverage, so we have an easy to test method
 func main() {
 	argv := os.Args[1:len(os.Args)] // remove go-testcov
+	exitFunction(runSubcommand(argv))
+}
+
+// pick what to run, unexpected panics become an error message instead of a stack trace
+func runSubcommand(argv []string) (exitCode int) {
+	defer recoverAsError(&exitCode)
 
 	// print out version instead of go version when asked
 	if len(argv) == 1 && argv[0] == "version" {
 		fmt.Println(version)
-		exitFunction(0)
-	} else { // wrapping in else in case exitFunction was stubbed
-		exitFunction(runGoTestAndCheckCoverage(argv))
+		return 0
+	} else if len(argv) >= 1 && argv[0] == "check" {
+		return checkProfiles(argv[1:])
+	} else if len(argv) >= 1 && argv[0] == "ignores" {
+		return runGoTestAndCheckCoverage(append([]string{"--list-ignores"}, argv[1:]...))
 	}
+	return runGoTestAndCheckCoverage(argv)
 }
 
 // run go test with given arguments + coverage and inspect coverage after run
 func runGoTestAndCheckCoverage(argv []string) (exitCode int) {
+	options, argv, err := loadOptions(argv)
+	if err == nil && len(options.profiles) != 0 {
+		err = ConfigError{fmt.Errorf("--profile only works with `go-testcov check`, which does not run tests")}
+	}
+	var runner Runner
+	if err == nil {
+		runner, err = newRunner(argv, options.runnerCmd)
+		if err != nil {
+			err = ConfigError{err}
+		}
+	}
+	if err != nil {
+		return exitWithError(err)
+	}
+
+	// reuse the profile location the user asked for
+	flags := parseGoTestFlags(append(slices.Clone(options.config.flags), runner.args...))
 	coveragePath := "coverage.out"
-	_ = os.Remove(coveragePath) // remove file if it exists, to avoid confusion when test run fails
-
-	// allow users to keep the coverage.out file when they passed -cover manually
-	// TODO: parse options to find the location the user wanted and use+keep that
-	if !slices.Contains(argv, "-cover") {
-		defer os.Remove(coveragePath)
-	}
-
-	var command []string
-	// user trying to use ginkgo binary, or locally installed one ?
-	if len(argv) >= 1 && strings.HasSuffix("/"+argv[0], "/ginkgo") {
-		// - files (i.e. ./...) need to come last
-		// - subcommands need to come first, see https://github.com/onsi/ginkgo/issues/1531
-		length := len(argv)
-		command = argv[0 : length-1]
-		command = append(command, "-cover", "-coverprofile", coveragePath, argv[length-1])
-	} else {
-		command = append(append([]string{"go", "test"}, argv...), "-coverprofile", coveragePath)
+	if flags.coverProfile != "" {
+		coveragePath = flags.coverProfile
+	}
+	readPath := coveragePath
+	if flags.outputDir != "" && !filepath.IsAbs(coveragePath) {
+		readPath = filepath.Join(flags.outputDir, coveragePath)
 	}
+	_ = os.Remove(readPath) // remove file if it exists, to avoid confusion when test run fails
 
-	exitCode = runCommand(command...)
+	// allow users to keep the coverage file when they passed -cover or -coverprofile manually
+	if !flags.cover && flags.coverProfile == "" {
+		defer os.Remove(readPath)
+	}
+
+	// never pass flags twice
+	coverageFlags := []string{}
+	if flags.coverProfile == "" {
+		coverageFlags = append(coverageFlags, "-coverprofile", coveragePath)
+	}
+
+	if runner.needsCover && !flags.cover {
+		coverageFlags = append([]string{"-cover"}, coverageFlags...)
+	}
+
+	exitCode = runCommand(runner.command(options.config.flags, coverageFlags)...)
 
 	if exitCode != 0 {
 		return exitCode
 	}
-	return checkCoverage(coveragePath)
+
+	// binaries that tests ran wrote their coverage to GOCOVERDIR
+	sections, err := loadSections([]string{readPath}, options.covDirs)
+	if err == nil {
+		exitCode, err = checkCoverage(sections, options)
+	}
+	if err != nil {
+		return exitWithError(err)
+	}
+	return exitCode
+}
+
+// inspect coverage of existing profiles without running tests, for example from sharded CI runs or bazel
+func checkProfiles(argv []string) (exitCode int) {
+	options, paths, err := loadOptions(argv)
+	paths = append(options.profiles, paths...)
+	if err == nil && len(paths) == 0 && len(options.covDirs) == 0 {
+		err = ConfigError{fmt.Errorf("check needs a coverage profile, like `go-testcov check --profile coverage.out` or `--covdir=dir`")}
+	}
+	for _, path := range paths {
+		if err == nil && strings.HasPrefix(path, "-") {
+			err = ConfigError{fmt.Errorf("check does not run tests, so %v is not supported", path)}
+		}
+	}
+
+	var sections []Section
+	if err == nil {
+		sections, err = loadSections(paths, options.covDirs)
+	}
+	if err == nil {
+		exitCode, err = checkCoverage(sections, options)
+	}
+	if err != nil {
+		return exitWithError(err)
+	}
+	return exitCode
+}
+
+// options from argv, .go-testcov.yml and the baseline, remaining arguments are for `go test`
+func loadOptions(argv []string) (options Options, remaining []string, err error) {
+	wd, err := os.Getwd()
+	check(err)
+	config, err := findConfig(wd)
+	if err == nil {
+		options, remaining, err = parseOptions(argv, config)
+	}
+	if err == nil && options.diff != "" {
+		err = verifyRef(options.diff)
+	}
+
+	// when updating, the old baseline must not hide files that it configured
+	if err == nil && !options.updateBaseline {
+		options.baseline, err = readBaseline(baselineFileName)
+	}
+
+	if err != nil {
+		return options, remaining, ConfigError{err} // everything that goes wrong here is for the user to fix
+	}
+	return
 }
 
 // check coverage for each path that has coverage
-func checkCoverage(coverageFilePath string) (exitCode int) {
+func checkCoverage(sections []Section, options Options) (exitCode int, err error) {
 	exitCode = 0
-	sectionsByPath := groupSectionsByPath(getSections(coverageFilePath))
+	report := Report{Files: []FileReport{}}
+	sectionsByPath := groupSectionsByPath(sections)
+	total := TotalReport{Unit: options.unit, MinimumPercent: options.minTotal}
 
 	wd, err := os.Getwd()
 	check(err)
+	workspace, err := findWorkspace(wd)
+	if err != nil {
+		return 0, err
+	}
+	if options.format == formatGitHub || options.format == formatSarif {
+		report.root = findRepositoryRoot(wd)
+	}
+
+	// only untested sections in changed lines matter when comparing to a git ref
+	var changed map[string][]LineRange
+	if options.diff != "" {
+		if changed, err = changedLines(options.diff); err != nil {
+			return 0, err
+		}
+	}
+
+	// print as we go, so output is in sync with warnings printed while checking
+	// text stays on stderr when the machine-readable output goes to a file
+	printText := !options.updateBaseline && !options.listIgnores && (options.format == formatText || options.report != "")
+	color := useColor(options.noColor)
 
 	iterateBySortedKey(sectionsByPath, func(path string, sections []Section) {
+		if err != nil {
+			return // stop at the first error
+		}
+		displayPath, readPath := normalizeCoveredPath(path, wd, workspace)
+
 		// skip generated files since their coverage does not matter and would often have gaps
-		if generatedFile.MatchString(path) {
+		generated := generatedFile.MatchString(path) || options.config.isGenerated(readPath)
+		content, readErr := os.ReadFile(readPath)
+		if readErr != nil && !generated {
+			err = ProfileError{path: path, err: fmt.Errorf("covered file could not be read: %w", readErr)}
+			return
+		}
+		if generated || hasGeneratedHeader(string(content)) {
+			if options.verbose {
+				_, _ = fmt.Fprintf(os.Stderr, "go-testcov: skipping generated file %v\n", displayPath)
+			}
 			return
 		}
 
-		displayPath, readPath := normalizeCoveredPath(path, wd)
-		configuredUntested, percentUntested, configuredUntestedAtLine := configuredUntestedForFile(readPath)
-		lines := strings.Split(readFile(readPath), "\n")
-
-		// print warnings logs for covered sections
-		warnCoveredInlineIgnore(displayPath, sections, lines)
-
-		untested := removeSectionsMarkedWithInlineComment(untestedFromSections(sections), lines)
-		actualUntested := len(untested)
-		actualUntestedPercent := int(math.Round(float64(actualUntested) / float64(len(lines)) * 100))
+		total.Total += countIn(sections, options.unit)
+		total.Covered += countIn(sections, options.unit) - countIn(untestedFromSections(sections), options.unit)
 
-		// what to show the user
-		var details string
-		if percentUntested {
-			details = fmt.Sprintf("(%v%% current vs %v%% configured)", actualUntestedPercent, configuredUntested)
-		} else {
-			details = fmt.Sprintf("(%v current vs %v configured)", actualUntested, configuredUntested)
+		var file FileReport
+		file, err = checkFile(displayPath, readPath, string(content), sections, options, wd, changed)
+		if err == nil && options.fix {
+			file, err = fixFile(file, string(content))
 		}
+		if err != nil {
+			return
+		}
+		report.Files = append(report.Files, file)
 
-		if (!percentUntested && actualUntested == configuredUntested) || (percentUntested && actualUntestedPercent <= configuredUntested) {
-			// exactly as much as we expected, ignored (0%), or <= % than configured: nothing to do
-		} else if actualUntested > configuredUntested {
-			printUntestedSections(untested, displayPath, details)
-			exitCode = 1 // at least 1 failure, so say to add more tests
-		} else { // never hit in % case
-			_, _ = fmt.Fprintf(
-				os.Stderr,
-				"%v has less untested sections %v, decrement configured untested?\nconfigured on: %v:%v",
-				displayPath, details, readPath, configuredUntestedAtLine)
+		if printText {
+			printFileReport(file, color)
 		}
 	})
+	if err != nil {
+		return 0, err
+	}
 
-	return exitCode
+	// only show what is ignored, checking is for the next run
+	if options.listIgnores {
+		writeIgnores(os.Stdout, report)
+		return 0, nil
+	}
+
+	// accept the current state instead of failing
+	if options.updateBaseline {
+		return 0, writeBaseline(baselineFileName, report, wd)
+	}
+
+	report.Packages = checkPackages(report.Files)
+
+	if options.minTotal != 0 {
+		if total.Total != 0 {
+			total.Percent = int(math.Floor(float64(total.Covered) / float64(total.Total) * 100)) // never round up to the minimum
+		}
+		total.Status = statusPass
+		if total.Percent < total.MinimumPercent {
+			total.Status = statusFail
+		}
+		report.Total = &total
+	}
+
+	if printText {
+		printSummary(report, color)
+	}
+	if err := writeReport(report, options); err != nil {
+		return 0, err
+	}
+
+	// at least 1 failure, so say to add more tests
+	for _, file := range report.Files {
+		if file.Status == statusFail {
+			exitCode = exitCoverage
+		}
+		for _, warning := range file.Warnings {
+			if warning.level() == "error" {
+				exitCode = exitCoverage
+			}
+		}
+	}
+	for _, pkg := range report.Packages {
+		if pkg.Status == statusFail {
+			exitCode = exitCoverage
+		}
+	}
+	if report.Total != nil && report.Total.Status == statusFail {
+		exitCode = exitCoverage
+	}
+	return exitCode, nil
 }
 
-func printUntestedSections(sections []Section, displayPath string, details string) {
-	// TODO: color when tty
-	_, _ = fmt.Fprintf(os.Stderr, "%v new untested sections introduced %v\n", displayPath, details)
+// compare the untested sections of a file to what is configured for it
+func checkFile(displayPath string, readPath string, content string, sections []Section, options Options, workingDirectory string, changed map[string][]LineRange) (file FileReport, err error) {
+	file.DisplayPath, file.ReadPath = displayPath, readPath
+	file.Unit = options.unit
+
+	// comment in the file wins over the package comment in doc.go, the config file and the baseline
+	if filepath.Base(readPath) != "doc.go" {
+		file.ConfiguredUntested, file.Percent, file.ConfiguredAtLine, err = configuredUntestedForFile(readPath, content)
+		if err != nil {
+			return file, err
+		}
+	}
+	packageBudget, docPath, err := configuredUntestedForPackage(readPath)
+	if err != nil {
+		return file, err
+	}
+	if file.ConfiguredAtLine != 0 {
+		file.ConfiguredIn = readPath
+	} else if packageBudget.ConfiguredAtLine != 0 {
+		file.ConfiguredUntested, file.Percent, file.ConfiguredAtLine = packageBudget.ConfiguredUntested, packageBudget.Percent, packageBudget.ConfiguredAtLine
+		file.ConfiguredIn = docPath
+		file.Package = filepath.Dir(displayPath)
+	} else if untested, found := options.config.untestedForFile(readPath); found {
+		file.ConfiguredUntested, file.Percent, file.ConfiguredAtLine = untested.count, untested.percent, untested.line
+		file.ConfiguredIn = relativeToWorkingDirectory(options.config.path, workingDirectory)
+	} else if count, line, found := options.baseline.untestedForFile(readPath, workingDirectory); found {
+		file.ConfiguredUntested, file.ConfiguredAtLine = count, line
+		file.ConfiguredIn = options.baseline.path
+	}
+	lines := strings.Split(content, "\n")
+	file.Ignores, err = findIgnores(displayPath, content)
+	if err != nil {
+		return file, err
+	}
+
+	allUntested := untestedFromSections(sections)
+	untested := removeIgnoredSections(readPath, content, sections, allUntested)
+	file.Ignores = countSuppressed(file, content, sections, allUntested, untested)
+
+	file.Warnings = findIgnoreWarnings(file, content, sections)
+	for _, ignore := range file.Ignores {
+		if ignore.Expired {
+			file.Warnings = append(file.Warnings, Warning{ignore.Line, fmt.Sprintf("has an ignore marker that expired on %v", ignore.Until), ruleExpiredIgnore})
+		}
+	}
+	if options.strict {
+		file.Warnings = append(file.Warnings, findMissingReasons(file.Ignores)...)
+	}
+
+	// new code must be tested, so budgets for existing untested code do not apply unless the file is ignored
+	if changed != nil {
+		if file.Percent && file.ConfiguredUntested >= 100 {
+			untested = []Section{}
+		} else {
+			untested = sectionsInChangedLines(untested, changedLinesForFile(changed, file.ReadPath, workingDirectory))
+			file.ConfiguredUntested, file.Percent = 0, false
+			file.Package = ""
+		}
+	}
 
 	// sort sections since go coverage output is not sorted
-	sort.Slice(sections, func(i, j int) bool {
-		return sections[i].sortValue < sections[j].sortValue
+	sort.Slice(untested, func(i, j int) bool {
+		return untested[i].sortValue < untested[j].sortValue
 	})
+	file.Untested = untested
+	if len(untested) != 0 {
+		file.Functions = functionReports(readPath, content, sections, untested, file.Unit)
+	}
 
-	// print copy-paste friendly snippets
-	for _, section := range sections {
-		_, _ = fmt.Fprintln(os.Stderr, displayPath+":"+section.Location())
+	file.ActualUntested = countIn(untested, file.Unit)
+
+	// like `go tool cover -func`, unless the old untested sections per line are wanted
+	file.PercentOf = options.percentOf
+	if file.PercentOf == unitLines {
+		file.PercentUntested, file.PercentTotal = file.ActualUntested, len(lines)
+	} else {
+		file.PercentUntested, file.PercentTotal = countIn(untested, unitStatements), countIn(sections, unitStatements)
+	}
+	file.ActualUntestedPercent = percent(file.PercentUntested, file.PercentTotal)
+
+	if file.Package != "" {
+		file.Status = statusPackage
+	} else {
+		file.decide()
 	}
+	return
 }
 
-// keep untested sections that are marked with "untested section" comment
+// sum up the files that are configured by their package
+func checkPackages(files []FileReport) (packages []PackageReport) {
+	packages = []PackageReport{}
+	positions := map[string]int{} // directory -> index in packages
+	for _, file := range files {
+		if file.Package == "" {
+			continue
+		}
+
+		// files of a package are not next to each other when it has sub-packages, a/b.go < a/b/c.go < a/d.go
+		position, found := positions[file.Package]
+		if !found {
+			position = len(packages)
+			positions[file.Package] = position
+			packages = append(packages, PackageReport{Directory: file.Package, Files: []string{}})
+			packages[position].Budget = Budget{
+				ConfiguredUntested: file.ConfiguredUntested,
+				Percent:            file.Percent,
+				Unit:               file.Unit,
+				PercentOf:          file.PercentOf,
+				ConfiguredIn:       file.ConfiguredIn,
+				ConfiguredAtLine:   file.ConfiguredAtLine,
+			}
+		}
+		pkg := &packages[position]
+		pkg.Files = append(pkg.Files, file.DisplayPath)
+		pkg.ActualUntested += file.ActualUntested
+		pkg.PercentUntested += file.PercentUntested
+		pkg.PercentTotal += file.PercentTotal
+	}
+
+	for i := range packages {
+		packages[i].ActualUntestedPercent = percent(packages[i].PercentUntested, packages[i].PercentTotal)
+		packages[i].decide()
+	}
+	return
+}
+
+func percent(part int, whole int) int {
+	if whole == 0 {
+		return 0
+	}
+	return int(math.Round(float64(part) / float64(whole) * 100))
+}
+
+// keep untested sections that are marked with "untested section" comment, for files that removeIgnoredSections cannot parse
 // need to be careful to not change the list while iterating, see https://pauladamsmith.com/blog/2016/07/go-modify-slice-iteration.html
 // NOTE: this is a bit rough as it does not account for partial lines via start/end characters
 func removeSectionsMarkedWithInlineComment(sections []Section, lines []string) []Section {
@@ -151,7 +441,7 @@ func removeSectionsMarkedWithInlineComment(sections []Section, lines []string) [
 			continue
 		}
 
-		// same inline-ignore rules as warnCoveredInlineIgnore, keep the two in sync
+		// same inline-ignore rules as findCoveredInlineIgnores, keep the two in sync
 		for lineNumber := section.startLine; lineNumber <= section.endLine; lineNumber++ {
 			if anyInlineIgnore.MatchString(lines[lineNumber-1]) {
 				break // section is ignored
@@ -208,21 +498,28 @@ func groupSectionsByPath(sections []Section) (grouped map[string][]Section) {
 	return
 }
 
-// get all sections from coverage file
-func getSections(coverageFilePath string) (sections []Section) {
+// get the mode and all sections from coverage file
+func getSections(coverageFilePath string) (mode string, sections []Section, err error) {
+	content, err := os.ReadFile(coverageFilePath)
+	if err != nil {
+		return "", nil, ProfileError{err: err}
+	}
+
 	sections = []Section{}
-	content := readFile(coverageFilePath)
+	lines := strings.Split(string(content), "\n")
 
-	lines := splitWithoutEmpty(content, '\n')
+	// remove the initial `mode: set` line
+	mode = strings.TrimPrefix(lines[0], "mode: ")
 
-	// remove the initial `set: mode` line
-	if len(lines) == 0 {
-		return
-	}
-	lines = lines[1:]
-
-	for _, line := range lines {
-		sections = append(sections, NewSection(line))
+	for i, line := range lines[1:] {
+		if line == "" {
+			continue
+		}
+		section, err := NewSection(line)
+		if err != nil {
+			return "", nil, ProfileError{coverageFilePath, i + 2, err}
+		}
+		sections = append(sections, section)
 	}
 
 	return
@@ -239,8 +536,9 @@ func untestedFromSections(sections []Section) (untested []Section) {
 	return
 }
 
-// warn when inline ignore markers point to code that is actually covered
-func warnCoveredInlineIgnore(path string, sections []Section, lines []string) {
+// find inline ignore markers that point to code that is actually covered
+func findCoveredInlineIgnores(sections []Section, lines []string) (warnings []Warning) {
+	warnings = []Warning{}
 	for i, line := range lines {
 		sourceLine := i + 1
 
@@ -251,19 +549,12 @@ func warnCoveredInlineIgnore(path string, sections []Section, lines []string) {
 
 		// same inline-ignore rules as removeSectionsMarkedWithInlineComment, keep the two in sync
 		if anyInlineIgnore.MatchString(line) && allSectionsOnLineCovered(sections, sourceLine) {
-			_, _ = fmt.Fprintf(
-				os.Stderr,
-				"go-testcov (warn): %v:%v has `// untested section` but is tested\n",
-				path, sourceLine,
-			)
+			warnings = append(warnings, Warning{sourceLine, "has `// untested section` but is tested", ruleTestedIgnore})
 		} else if startsWithInlineIgnore.MatchString(line) && allSectionsStartingAtLineCovered(sections, sourceLine+1) {
-			_, _ = fmt.Fprintf(
-				os.Stderr,
-				"go-testcov (warn): %v:%v has `// untested section` but the code below is tested\n",
-				path, sourceLine,
-			)
+			warnings = append(warnings, Warning{sourceLine, "has `// untested section` but the code below is tested", ruleTestedIgnore})
 		}
 	}
+	return
 }
 
 // true when at least one section spans this source line and all such sections are covered
@@ -294,6 +585,19 @@ func allSectionsStartingAtLineCovered(sections []Section, line int) bool {
 	return covered
 }
 
+// generated code has a `// Code generated ... DO NOT EDIT.` line before the package clause, see go/ast.IsGenerated
+func hasGeneratedHeader(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if generatedHeader.MatchString(line) {
+			return true
+		} else if packageClause.MatchString(line) {
+			return false
+		}
+	}
+	return false
+}
+
 // find relative path of file in current directory
 func findFile(path string) (readPath string) {
 	parts := strings.Split(path, string(os.PathSeparator))
@@ -308,8 +612,13 @@ func findFile(path string) (readPath string) {
 	return strings.Join(parts, string(os.PathSeparator))
 }
 
-// remove path prefix like "github.com/user/lib", but cache the call to os.Get
-func normalizeCoveredPath(path string, workingDirectory string) (displayPath string, readPath string) {
+// remove path prefix like "github.com/user/lib" by finding the module in go.work or go.mod
+// without modules guess by removing the first 3 parts and looking in GOPATH
+func normalizeCoveredPath(path string, workingDirectory string, workspace Workspace) (displayPath string, readPath string) {
+	if file, found := workspace.resolve(path); found {
+		return workspace.displayPath(file), relativeToWorkingDirectory(file, workingDirectory)
+	}
+
 	modulePrefixSize := 3 // foo.com/bar/baz + file.go
 	separator := string(os.PathSeparator)
 	parts := strings.SplitN(path, separator, modulePrefixSize+1)
@@ -348,6 +657,20 @@ func normalizeCoveredPath(path string, workingDirectory string) (displayPath str
 	return path, goPrefixedPath
 }
 
+// doc.go can configure how many sections are expected to be untested in the whole package
+func configuredUntestedForPackage(readPath string) (budget Budget, docPath string, err error) {
+	docPath = filepath.Join(filepath.Dir(readPath), "doc.go")
+	if _, err := os.Stat(docPath); err != nil {
+		return budget, docPath, nil
+	}
+	content, err := os.ReadFile(docPath)
+	if err != nil {
+		return budget, docPath, ConfigError{err}
+	}
+	budget.ConfiguredUntested, budget.Percent, budget.ConfiguredAtLine, err = configuredUntestedForFile(docPath, string(content))
+	return
+}
+
 // How many sections are expected to be untested ?
 //
 // - 0 if not configured
@@ -356,21 +679,21 @@ func normalizeCoveredPath(path string, workingDirectory string) (displayPath str
 // - 100% if "ignore"
 //
 // also returns at what line we found the comment, so we can point the user to it
-func configuredUntestedForFile(path string) (count int, percent bool, lineNumber int) {
-	content := readFile(path)
+func configuredUntestedForFile(path string, content string) (count int, percent bool, lineNumber int, err error) {
 	match := perFileIgnore.FindStringSubmatch(content)
 	if len(match) == 2 { // found a config ?
-		config := match[1]
-		line := lineNumberOfMatch(content)
-
-		if config == "ignore" {
-			return 100, true, line // 100% which does not warn for any amount, so basically ignored
-		} else if strings.HasSuffix(config, "%") {
-			return stringToInt(config[:len(config)-1]), true, line // percent
-		} else {
-			return stringToInt(config), false, line // count
+		lineNumber = lineNumberOfMatch(content)
+		count, percent, err := parseConfiguredUntested(match[1])
+		if err != nil {
+			return 0, false, 0, ConfigError{fmt.Errorf("%v:%v: %v", path, lineNumber, err)}
+		}
+		if _, expired, err := markerExpiry(strings.Split(content, "\n")[lineNumber-1]); err != nil {
+			return 0, false, 0, ConfigError{fmt.Errorf("%v:%v: %v", path, lineNumber, err)}
+		} else if expired {
+			return 0, false, 0, nil // same as not configured, so the untested code fails
 		}
+		return count, percent, lineNumber, nil
 	} else {
-		return 0, false, 0
+		return 0, false, 0, nil
 	}
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// scope is the part of the source a marker applies to
+type scope struct {
+	start int // line*100000+column, like Section.sortValue
+	end   int
+}
+
+// remove untested sections that are marked with `// untested section` or `// untested block`
+// in go files the marker applies to the syntax node it is on or directly above,
+// files that do not parse fall back to removeSectionsMarkedWithInlineComment which works on lines
+func removeIgnoredSections(path string, content string, sections []Section, untested []Section) []Section {
+	scopesByLine := markerScopes(path, content)
+	if scopesByLine == nil {
+		return removeSectionsMarkedWithInlineComment(untested, withoutExpiredMarkers(strings.Split(content, "\n")))
+	}
+
+	scopes := []scope{}
+	for _, lineScopes := range scopesByLine {
+		scopes = append(scopes, lineScopes...)
+	}
+	return outsideScopes(untested, sections, scopes)
+}
+
+// what each marker applies to by the line it is on, nil when the file does not parse
+// markers that apply to nothing are on their line without scopes, so they can be warned about
+func markerScopes(path string, content string) (scopesByLine map[int][]scope) {
+	fileSet := token.NewFileSet()
+	parsed, err := parser.ParseFile(fileSet, path, content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil
+	}
+
+	scopesByLine = map[int][]scope{}
+	add := func(comment *ast.Comment, node ast.Node) {
+		line := fileSet.Position(comment.Pos()).Line
+		scopesByLine[line] = append(scopesByLine[line], scope{position(fileSet, node.Pos()), position(fileSet, node.End())})
+	}
+
+	nodes := scopableNodes(parsed)
+	for _, group := range parsed.Comments {
+		for _, comment := range group.List {
+			inline := anyInlineIgnore.MatchString(comment.Text) || blockIgnore.MatchString(comment.Text)
+			if !inline && !ignoreDirective.MatchString(comment.Text) || isExpired(comment.Text) {
+				continue
+			}
+			line := fileSet.Position(comment.Pos()).Line
+			scopesByLine[line] = scopesByLine[line]
+			if node := markedNode(fileSet, nodes, group, comment); inline && node != nil {
+				add(comment, node)
+			}
+		}
+	}
+
+	// whole functions, no matter how long the signature is
+	for _, decl := range parsed.Decls {
+		if function, ok := decl.(*ast.FuncDecl); ok {
+			if comment := functionIgnore(function); comment != nil {
+				add(comment, function)
+			}
+		}
+	}
+	return
+}
+
+// remove markers that expired from the lines, so they are not found
+func withoutExpiredMarkers(lines []string) []string {
+	kept := slices.Clone(lines)
+	for i, line := range kept {
+		if location := anyInlineIgnore.FindStringIndex(line); location != nil && isExpired(line[location[0]:]) {
+			kept[i] = line[:location[0]]
+		} else if blockIgnore.MatchString(line) && isExpired(line) {
+			kept[i] = ""
+		}
+	}
+	return kept
+}
+
+func outsideScopes(untested []Section, sections []Section, scopes []scope) (kept []Section) {
+	kept = []Section{}
+	for _, section := range untested {
+		if !isInScopes(section, sections, scopes) {
+			kept = append(kept, section)
+		}
+	}
+	return
+}
+
+// statements, declarations and func literals in source order, outer nodes before the nodes inside them
+func scopableNodes(file *ast.File) (nodes []ast.Node) {
+	ast.Inspect(file, func(node ast.Node) bool {
+		switch node.(type) {
+		case ast.Stmt, ast.Decl, *ast.FuncLit:
+			nodes = append(nodes, node)
+		}
+		return true
+	})
+	return
+}
+
+// node a marker applies to, nil when it is not on or above code
+//   - `if x { // marker` and `case 1: // marker` apply to the block or clause the marker is in
+//   - `foo() // marker` applies to the statement on that line
+//   - a marker on its own line applies to the statement or declaration on the next line
+func markedNode(fileSet *token.FileSet, nodes []ast.Node, group *ast.CommentGroup, comment *ast.Comment) ast.Node {
+	line := fileSet.Position(comment.Pos()).Line
+
+	// innermost node that was opened on the line of the marker
+	var containing ast.Node
+	for _, node := range nodes {
+		if node.Pos() < comment.Pos() && comment.Pos() < node.End() && fileSet.Position(node.Pos()).Line == line {
+			containing = node
+		}
+	}
+	if containing != nil {
+		return containing
+	}
+
+	// outermost node that starts or ends on the line of the marker
+	for _, node := range nodes {
+		if node.End() <= comment.Pos() && (fileSet.Position(node.Pos()).Line == line || fileSet.Position(node.End()).Line == line) {
+			return node
+		}
+	}
+
+	// outermost node on the line below the comment
+	nextLine := fileSet.Position(group.End()).Line + 1
+	for _, node := range nodes {
+		if node.Pos() > group.End() && fileSet.Position(node.Pos()).Line == nextLine {
+			return node
+		}
+	}
+	return nil
+}
+
+// `//testcov:ignore` directive or `// untested section` in the doc comment, which ignores the function with everything inside it
+func functionIgnore(function *ast.FuncDecl) *ast.Comment {
+	if function.Doc == nil {
+		return nil
+	}
+	for _, comment := range function.Doc.List {
+		if (ignoreDirective.MatchString(comment.Text) || anyInlineIgnore.MatchString(comment.Text)) && !isExpired(comment.Text) {
+			return comment
+		}
+	}
+	return nil
+}
+
+// sections inside the scope are ignored, a scope without sections (a simple statement) ignores the section that runs it
+func isInScopes(section Section, sections []Section, scopes []scope) bool {
+	for _, scope := range scopes {
+		if scope.contains(section) {
+			return true
+		}
+		if section.sortValue <= scope.start && scope.start <= endValue(section) && !scope.containsAny(sections) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s scope) contains(section Section) bool {
+	return s.start <= section.sortValue && endValue(section) <= s.end
+}
+
+func (s scope) containsAny(sections []Section) bool {
+	for _, section := range sections {
+		if s.contains(section) {
+			return true
+		}
+	}
+	return false
+}
+
+// where the section ends, comparable to sortValue
+func endValue(section Section) int {
+	return section.endLine*100000 + section.endChar
+}
+
+func position(fileSet *token.FileSet, pos token.Pos) int {
+	position := fileSet.Position(pos)
+	return position.Line*100000 + position.Column
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Options are the go-testcov specific flags, everything else is passed on to `go test`
+type Options struct {
+	diff           string   // git ref to compare against, only changed lines need to be tested
+	format         string   // how to output the verdict
+	unit           string   // count untested sections or statements
+	percentOf      string   // what configured percentages of untested are relative to
+	report         string   // file to write the report to, text output stays on stderr
+	config         Config   // from .go-testcov.yml
+	baseline       Baseline // from .testcov-baseline.json
+	updateBaseline bool     // write the baseline instead of failing
+	verbose        bool     // explain what is skipped
+	noColor        bool     // plain text even in a terminal, NO_COLOR also works
+	fix            bool     // rewrite comments that configure more untested than there is
+	strict         bool     // ignore markers need a reason
+	listIgnores    bool     // print all ignore markers instead of checking coverage
+	minTotal       int      // minimum percent of covered sections in all files, 0 when not configured
+	profiles       []string // existing coverage profiles to check instead of running tests
+	covDirs        []string // GOCOVERDIR directories with coverage of built binaries
+	runnerCmd      string   // custom test command with a {coverprofile} placeholder
+}
+
+var formats = []string{formatText, formatJSON, formatGitHub, formatSarif}
+var units = []string{unitSections, unitStatements}
+var percentOfs = []string{unitStatements, unitLines}
+
+// extract go-testcov options from argv and return the remaining arguments for `go test`
+// options are prefixed with `--` and take values as `--name=value`, paths also work as `--profile value`
+func parseOptions(argv []string, config Config) (options Options, remaining []string, err error) {
+	options.config = config
+	options.format = formatText
+	options.unit = unitSections
+	options.percentOf = unitStatements
+	if config.format != "" {
+		options.format = config.format
+	}
+	remaining = []string{}
+	for i := 0; i < len(argv); i++ {
+		arg := argv[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--diff":
+			if !hasValue {
+				value = "HEAD" // uncommitted changes
+			}
+			options.diff = value
+		case "--format":
+			if !slices.Contains(formats, value) {
+				return options, remaining, fmt.Errorf("unknown --format %q, use one of %v", value, strings.Join(formats, ", "))
+			}
+			options.format = value
+		case "--unit":
+			if !slices.Contains(units, value) {
+				return options, remaining, fmt.Errorf("unknown --unit %q, use one of %v", value, strings.Join(units, ", "))
+			}
+			options.unit = value
+		case "--percent-of":
+			if !slices.Contains(percentOfs, value) {
+				return options, remaining, fmt.Errorf("unknown --percent-of %q, use one of %v", value, strings.Join(percentOfs, ", "))
+			}
+			options.percentOf = value
+		case "--report":
+			options.report = value
+		case "--verbose":
+			options.verbose = true
+		case "--no-color":
+			options.noColor = true
+		case "--update-baseline":
+			options.updateBaseline = true
+		case "--fix":
+			options.fix = true
+		case "--strict":
+			options.strict = true
+		case "--list-ignores":
+			options.listIgnores = true
+		case "--profile", "--covdir":
+			if !hasValue {
+				if i+1 == len(argv) {
+					return options, remaining, fmt.Errorf("%v needs a path", name)
+				}
+				i++
+				value = argv[i]
+			}
+			if name == "--profile" {
+				options.profiles = append(options.profiles, value)
+			} else {
+				options.covDirs = append(options.covDirs, value)
+			}
+		case "--runner-cmd":
+			options.runnerCmd = value
+		case "--min-total":
+			minTotal, err := strconv.Atoi(strings.TrimSuffix(value, "%"))
+			if err != nil || minTotal < 0 || minTotal > 100 {
+				return options, remaining, fmt.Errorf("--min-total needs a percentage like 85%%, got %q", value)
+			}
+			options.minTotal = minTotal
+		default:
+			remaining = append(remaining, arg)
+		}
+	}
+	return
+}
+
+// GoTestFlags are the coverage related `go test` flags the user passed, so we do not pass them twice
+type GoTestFlags struct {
+	cover        bool
+	coverProfile string
+	outputDir    string // where go test and ginkgo v2 write profiles to
+}
+
+// find coverage related flags, go accepts `-name value`, `-name=value` and `--name`
+func parseGoTestFlags(argv []string) (flags GoTestFlags) {
+	values := map[string]*string{
+		"coverprofile": &flags.coverProfile,
+		"outputdir":    &flags.outputDir,
+		"output-dir":   &flags.outputDir,
+	}
+	for i := 0; i < len(argv); i++ {
+		if argv[i] == "-args" {
+			break // everything after goes to the test binary
+		}
+		if !strings.HasPrefix(argv[i], "-") {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(argv[i][1:], "-"), "=")
+		if name == "cover" {
+			flags.cover = !hasValue || value == "true"
+		} else if target, ok := values[name]; ok {
+			if !hasValue && i+1 < len(argv) {
+				i++
+				value = argv[i]
+			}
+			*target = value
+		}
+	}
+	return
+}
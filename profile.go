@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// merge profiles and the coverage of built binaries in GOCOVERDIR directories
+func loadSections(paths []string, covDirs []string) (sections []Section, err error) {
+	if len(covDirs) != 0 {
+		path, err := covdataProfile(covDirs)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(path)
+		paths = append(slices.Clone(paths), path)
+	}
+	return mergeProfiles(paths)
+}
+
+// convert GOCOVERDIR directories to a text profile, `go build -cover` binaries write them since go 1.20
+func covdataProfile(covDirs []string) (path string, err error) {
+	file, err := os.CreateTemp("", "go-testcov-covdata-*.out")
+	check(err)
+	check(file.Close())
+
+	exitCode := runCommand("go", "tool", "covdata", "textfmt", "-i="+strings.Join(covDirs, ","), "-o="+file.Name())
+	if exitCode != 0 {
+		_ = os.Remove(file.Name())
+		return "", ProfileError{err: fmt.Errorf("go tool covdata failed with exit code %v", exitCode)}
+	}
+	return file.Name(), nil
+}
+
+// merge coverage profiles like `go tool cover` would, so each block is counted once
+// set mode only records if a block ran, count and atomic modes record how often,
+// so mixing set with the others, like `go build -cover` binaries and `go test -race`, only records if a block ran
+func mergeProfiles(paths []string) (merged []Section, err error) {
+	merged = []Section{}
+	mode := ""
+	modePath := ""
+	positions := map[string]int{} // block -> index in merged
+
+	for _, path := range paths {
+		profileMode, sections, err := getSections(path)
+		if err != nil {
+			return nil, err
+		}
+		if profileMode == "" {
+			continue // empty profile
+		}
+		if mode == "" {
+			mode, modePath = profileMode, path
+		} else if combined, ok := mergedMode(mode, profileMode); !ok {
+			return nil, ProfileError{err: fmt.Errorf("%v has mode %v but %v has mode %v, cannot merge", path, profileMode, modePath, mode)}
+		} else if combined != mode {
+			mode = combined
+			for i := range merged {
+				if merged[i].callCount > 0 {
+					merged[i].callCount = 1
+				}
+			}
+		}
+
+		for _, section := range sections {
+			block := section.path + ":" + section.Location()
+			position, found := positions[block]
+			if !found {
+				positions[block] = len(merged)
+				merged = append(merged, section)
+			} else if mode == "set" {
+				if section.callCount > 0 {
+					merged[position].callCount = 1
+				}
+			} else {
+				merged[position].callCount += section.callCount
+			}
+		}
+	}
+	return merged, nil
+}
+
+// mode of the merged profile, false for modes that cannot be merged
+func mergedMode(a string, b string) (mode string, ok bool) {
+	counts := map[string]bool{"count": true, "atomic": true}
+	switch {
+	case a == b, counts[a] && counts[b]:
+		return a, true
+	case a == "set" && counts[b], counts[a] && b == "set":
+		return "set", true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,323 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// output formats
+const formatText = "text"
+const formatJSON = "json"
+const formatGitHub = "github"
+const formatSarif = "sarif"
+
+// verdicts for a file or package
+const statusPass = "pass"
+const statusFail = "fail"
+const statusDecrement = "decrement" // less untested than configured
+const statusPackage = "package"     // verdict is in the package report
+
+// Report is the coverage verdict for all files
+type Report struct {
+	Files    []FileReport    `json:"files"`
+	Packages []PackageReport `json:"packages"`
+	Total    *TotalReport    `json:"total,omitempty"` // only when a minimum is configured
+	root     string          // repository root that finding paths are relative to, "" to keep them as they are
+}
+
+// Budget is how much is configured to be untested compared to how much actually is
+type Budget struct {
+	Status                string `json:"status"`
+	ConfiguredUntested    int    `json:"configuredUntested"`
+	Percent               bool   `json:"percent"`          // configured untested is a percentage
+	Unit                  string `json:"unit"`             // sections or statements
+	ConfiguredIn          string `json:"configuredIn"`     // file or .go-testcov.yml, "" when not configured
+	ConfiguredAtLine      int    `json:"configuredAtLine"` // 0 when not configured
+	ActualUntested        int    `json:"actualUntested"`
+	ActualUntestedPercent int    `json:"actualUntestedPercent"`
+	PercentUntested       int    `json:"percentUntested"` // what the actual percent is calculated from
+	PercentTotal          int    `json:"percentTotal"`
+	PercentOf             string `json:"percentOf"` // statements, or lines when opted into the old behaviour
+}
+
+// FileReport is the coverage verdict for a single file
+type FileReport struct {
+	DisplayPath string `json:"displayPath"`
+	ReadPath    string `json:"readPath"`
+	Budget
+	Package   string           `json:"package,omitempty"` // directory when the budget is configured for the whole package
+	Untested  []Section        `json:"untested"`
+	Functions []FunctionReport `json:"functions,omitempty"` // functions with untested sections, most untested first
+	Ignores   []Ignore         `json:"ignores,omitempty"`
+	Warnings  []Warning        `json:"warnings"`
+}
+
+// PackageReport is the coverage verdict for all files in a package that are configured by its doc.go
+type PackageReport struct {
+	Directory string `json:"directory"`
+	Budget
+	Files []string `json:"files"` // display paths
+}
+
+// TotalReport is the coverage of all files compared to the minimum
+type TotalReport struct {
+	Status         string `json:"status"`
+	Unit           string `json:"unit"`
+	Total          int    `json:"total"`
+	Covered        int    `json:"covered"`
+	Percent        int    `json:"percent"`
+	MinimumPercent int    `json:"minimumPercent"`
+}
+
+// Warning points at a line that needs attention, but does not fail the run
+type Warning struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+	Rule    string `json:"rule"` // what it is about, --fix only removes tested inline markers
+}
+
+// markers without reason fail with --strict, everything else only needs attention
+func (w Warning) level() string {
+	if w.Rule == ruleMissingReason {
+		return "error"
+	}
+	return "warning"
+}
+
+// decide the status by comparing actual to configured
+func (b *Budget) decide() {
+	if (!b.Percent && b.ActualUntested == b.ConfiguredUntested) || (b.Percent && b.ActualUntestedPercent <= b.ConfiguredUntested) {
+		b.Status = statusPass // exactly as much as we expected, ignored (0%), or <= % than configured: nothing to do
+	} else if b.Percent || b.ActualUntested > b.ConfiguredUntested {
+		b.Status = statusFail // above the % or more than configured
+	} else {
+		b.Status = statusDecrement
+	}
+}
+
+// what to show the user
+func (b Budget) details() string {
+	if b.Percent {
+		counts := fmt.Sprintf("%v of %v statements untested", b.PercentUntested, b.PercentTotal)
+		if b.PercentOf == unitLines {
+			counts = fmt.Sprintf("%v untested %v in %v lines", b.PercentUntested, b.Unit, b.PercentTotal)
+		}
+		return fmt.Sprintf("(%v%% current vs %v%% configured, %v)", b.ActualUntestedPercent, b.ConfiguredUntested, counts)
+	} else {
+		return fmt.Sprintf("(%v current vs %v configured)", b.ActualUntested, b.ConfiguredUntested)
+	}
+}
+
+// print human-readable warnings and verdict for a file
+func printFileReport(file FileReport, color bool) {
+	for _, warning := range file.Warnings {
+		label := colorize(color, colorYellow, "go-testcov (warn):")
+		if warning.level() == "error" {
+			label = colorize(color, colorRed, "go-testcov (error):")
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "%v %v:%v %v\n", label, file.DisplayPath, warning.Line, warning.Message)
+	}
+	printBudget(file.DisplayPath, file.Budget, []FileReport{file}, color)
+}
+
+// print human-readable verdicts that are only known after all files were checked
+func printSummary(report Report, color bool) {
+	for _, pkg := range report.Packages {
+		members := []FileReport{}
+		for _, file := range report.Files {
+			if file.Package == pkg.Directory {
+				members = append(members, file)
+			}
+		}
+		printBudget(pkg.Directory+" package", pkg.Budget, members, color)
+	}
+
+	if report.Total != nil && report.Total.Status == statusFail {
+		message := fmt.Sprintf("total coverage %v%% is below the minimum of %v%%", report.Total.Percent, report.Total.MinimumPercent)
+		_, _ = fmt.Fprintln(os.Stderr, colorize(color, colorRed, message))
+	}
+}
+
+// print the verdict, with color the untested code is shown so it does not need to be looked up
+func printBudget(name string, budget Budget, files []FileReport, color bool) {
+	switch budget.Status {
+	case statusFail:
+		message := fmt.Sprintf("%v new untested %v introduced %v", name, budget.Unit, budget.details())
+		_, _ = fmt.Fprintln(os.Stderr, colorize(color, colorRed, message))
+
+		// print copy-paste friendly snippets
+		for _, file := range files {
+			content, err := os.ReadFile(file.ReadPath)
+			for _, section := range file.Untested {
+				_, _ = fmt.Fprintln(os.Stderr, file.DisplayPath+":"+section.Location())
+				if color && err == nil {
+					_, _ = fmt.Fprint(os.Stderr, sourceContext(string(content), section))
+				}
+			}
+			for _, function := range file.Functions {
+				_, _ = fmt.Fprintf(
+					os.Stderr, "%v:%v %v: %v untested %v (%v%% of %v)\n",
+					file.DisplayPath, function.Line, function.Name, function.Untested, budget.Unit, function.PercentUntested, function.Total)
+			}
+		}
+	case statusDecrement:
+		message := fmt.Sprintf("%v has less untested %v %v, decrement configured untested?", name, budget.Unit, budget.details())
+		_, _ = fmt.Fprintf(
+			os.Stderr,
+			"%v\nconfigured on: %v:%v",
+			colorize(color, colorYellow, message), budget.ConfiguredIn, budget.ConfiguredAtLine)
+	}
+}
+
+// Finding is something to point out in the code, for formats that annotate code
+type Finding struct {
+	Level   string // error, warning or note
+	Rule    string
+	Path    string // "" when not about a file
+	Region  sarifRegion
+	Message string
+}
+
+// rules that findings belong to
+const ruleUntested = "untested-section"
+const ruleDecrement = "decrement-configured-untested"
+const ruleTestedIgnore = "tested-inline-ignore"
+const ruleExpiredIgnore = "expired-ignore"
+const ruleMisplacedIgnore = "misplaced-ignore"
+const ruleMissingReason = "ignore-without-reason"
+const ruleMinimumTotal = "minimum-total-coverage"
+
+// everything that needs attention, in the order it should be shown
+func (r Report) findings() (findings []Finding) {
+	findings = []Finding{}
+	budgetFindings := func(name string, budget Budget, files []FileReport) {
+		switch budget.Status {
+		case statusFail:
+			for _, file := range files {
+				for _, section := range file.Untested {
+					findings = append(findings, Finding{
+						"error", ruleUntested, file.ReadPath,
+						sarifRegion{section.startLine, section.startChar, section.endLine, section.endChar},
+						"new untested section introduced" + name + " " + budget.details(),
+					})
+				}
+			}
+		case statusDecrement:
+			findings = append(findings, Finding{
+				"note", ruleDecrement, budget.ConfiguredIn, sarifRegion{StartLine: budget.ConfiguredAtLine},
+				"less untested " + budget.Unit + name + " " + budget.details() + ", decrement configured untested?",
+			})
+		}
+	}
+
+	for _, file := range r.Files {
+		for _, warning := range file.Warnings {
+			findings = append(findings, Finding{warning.level(), warning.Rule, file.ReadPath, sarifRegion{StartLine: warning.Line}, warning.Message})
+		}
+		budgetFindings("", file.Budget, []FileReport{file})
+	}
+
+	for _, pkg := range r.Packages {
+		members := []FileReport{}
+		for _, file := range r.Files {
+			if file.Package == pkg.Directory {
+				members = append(members, file)
+			}
+		}
+		budgetFindings(" in package "+pkg.Directory, pkg.Budget, members)
+	}
+
+	if r.Total != nil && r.Total.Status == statusFail {
+		findings = append(findings, Finding{
+			"error", ruleMinimumTotal, "", sarifRegion{},
+			fmt.Sprintf("total coverage %v%% is below the minimum of %v%%", r.Total.Percent, r.Total.MinimumPercent),
+		})
+	}
+
+	for i := range findings {
+		if findings[i].Path != "" {
+			findings[i].Path = r.repositoryPath(findings[i].Path)
+		}
+	}
+	return
+}
+
+// annotations are resolved against the repository root, which is not the working directory when testing a module in a subdirectory
+// files outside of the repository keep their path
+func (r Report) repositoryPath(path string) string {
+	if r.root == "" {
+		return path
+	}
+	absolute, _ := filepath.Abs(path) // "" when it fails, which cannot be made relative
+	relative, err := filepath.Rel(r.root, absolute)
+	if err != nil || strings.HasPrefix(relative, "..") {
+		return path
+	}
+	return relative
+}
+
+// write machine-readable report to stdout or the --report file
+func writeReport(report Report, options Options) error {
+	format := options.format
+	if options.report == "" {
+		if format == formatText {
+			return nil // already printed while checking
+		}
+		return writeReportTo(os.Stdout, report, format)
+	}
+
+	if format == formatText {
+		format = formatJSON // text is already on stderr, so the file gets something machine-readable
+	}
+	file, err := os.Create(options.report)
+	if err != nil {
+		return ConfigError{fmt.Errorf("--report could not be written: %w", err)}
+	}
+	defer file.Close()
+	return writeReportTo(file, report, format)
+}
+
+func writeReportTo(out io.Writer, report Report, format string) error {
+	switch format {
+	case formatJSON:
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case formatGitHub:
+		writeGitHubAnnotations(out, report)
+	case formatSarif:
+		return writeSarif(out, report)
+	}
+	return nil
+}
+
+// workflow commands that show up inline in the pull request diff
+// https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+func writeGitHubAnnotations(out io.Writer, report Report) {
+	levels := map[string]string{"error": "error", "warning": "warning", "note": "notice"}
+	for _, finding := range report.findings() {
+		properties := []string{}
+		if finding.Path != "" {
+			properties = append(properties, "file="+escapeGitHubProperty(finding.Path), fmt.Sprintf("line=%v", finding.Region.StartLine))
+		}
+		if finding.Region.StartColumn != 0 {
+			properties = append(properties, fmt.Sprintf(
+				"endLine=%v,col=%v,endColumn=%v", finding.Region.EndLine, finding.Region.StartColumn, finding.Region.EndColumn,
+			))
+		}
+		properties = append(properties, "title=go-testcov")
+		_, _ = fmt.Fprintf(out, "::%v %v::%v\n", levels[finding.Level], strings.Join(properties, ","), escapeGitHubData(finding.Message))
+	}
+}
+
+func escapeGitHubData(data string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(data)
+}
+
+func escapeGitHubProperty(property string) string {
+	return strings.NewReplacer(":", "%3A", ",", "%2C").Replace(escapeGitHubData(property))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const coverprofilePlaceholder = "{coverprofile}"
+
+// Runner is how tests are run, so users can keep their test tool and we only add coverage flags
+type Runner struct {
+	prefix        []string // command before the go test flags, for example `gotestsum --format dots --`
+	args          []string // go test flags and packages
+	coverageFirst bool     // coverage flags go before the args, since packages need to come last
+	needsCover    bool     // -coverprofile alone does not enable coverage
+}
+
+// pick the runner by the binary the user wants to run, `go test` when they only passed go test arguments
+func newRunner(argv []string, runnerCmd string) (runner Runner, err error) {
+	if runnerCmd != "" {
+		template := strings.Fields(runnerCmd)
+		index := slices.Index(template, coverprofilePlaceholder)
+		if index == -1 {
+			return runner, fmt.Errorf("--runner-cmd needs %v where the coverage flags go", coverprofilePlaceholder)
+		}
+		rest := append(slices.Clone(template[index+1:]), argv...)
+		return Runner{prefix: template[:index], args: rest, coverageFirst: true}, nil
+	}
+
+	binary := ""
+	if len(argv) != 0 {
+		binary = "/" + argv[0]
+	}
+	switch {
+	case strings.HasSuffix(binary, "/ginkgo"):
+		// v2 subcommands need to come first, see https://github.com/onsi/ginkgo/issues/1531
+		split := 1
+		if len(argv) >= 2 && (argv[1] == "run" || argv[1] == "watch") {
+			split = 2
+		}
+		return Runner{prefix: argv[:split], args: argv[split:], coverageFirst: true, needsCover: true}, nil
+	case strings.HasSuffix(binary, "/gotestsum"):
+		// go test flags go after `--`
+		split := slices.Index(argv, "--")
+		if split == -1 {
+			return Runner{prefix: append(slices.Clone(argv), "--"), args: []string{}}, nil
+		}
+		return Runner{prefix: argv[:split+1], args: argv[split+1:]}, nil
+	case strings.HasSuffix(binary, "/richgo") && len(argv) >= 2 && argv[1] == "test":
+		return Runner{prefix: argv[:2], args: argv[2:]}, nil
+	default:
+		return Runner{prefix: []string{"go", "test"}, args: argv}, nil
+	}
+}
+
+// the command to run, default flags go before the args so users can override them
+func (r Runner) command(defaultFlags []string, coverageFlags []string) (command []string) {
+	command = append(slices.Clone(r.prefix), defaultFlags...)
+	if r.coverageFirst {
+		command = append(command, coverageFlags...)
+		return append(command, r.args...)
+	}
+	command = append(command, r.args...)
+	return append(command, coverageFlags...)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+)
+
+// SARIF 2.1.0 log, only the parts we use
+// https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+// write the report as a SARIF log so it can be shown next to lint findings
+func writeSarif(out io.Writer, report Report) error {
+	results := []sarifResult{}
+	for _, finding := range report.findings() {
+		result := sarifResult{RuleID: finding.Rule, Level: finding.Level, Message: sarifMessage{finding.Message}}
+		if finding.Path != "" {
+			result.Locations = sarifLocations(sarifURI(finding.Path), finding.Region)
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "go-testcov",
+				Version:        version,
+				InformationURI: "https://github.com/grosser/go-testcov",
+				Rules: []sarifRule{
+					{ruleUntested, sarifMessage{"Untested section"}},
+					{ruleDecrement, sarifMessage{"Less untested sections than configured"}},
+					{ruleTestedIgnore, sarifMessage{"Inline `// untested section` on tested code"}},
+					{ruleExpiredIgnore, sarifMessage{"Ignore marker after its until date"}},
+					{ruleMisplacedIgnore, sarifMessage{"Ignore marker that is not on or above code"}},
+					{ruleMissingReason, sarifMessage{"Ignore marker without reason, which --strict does not allow"}},
+					{ruleMinimumTotal, sarifMessage{"Total coverage below the minimum"}},
+				},
+			}},
+			Results: results,
+		}},
+	}
+
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+func sarifLocations(uri string, region sarifRegion) []sarifLocation {
+	return []sarifLocation{{sarifPhysicalLocation{sarifArtifactLocation{uri}, region}}}
+}
+
+// relative paths stay relative to the working directory, absolute paths (GOPATH) become file uris
+func sarifURI(path string) string {
+	if filepath.IsAbs(path) {
+		return "file://" + filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(path)
+}
@@ -1,9 +1,10 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"regexp"
-	"strings"
+	"strconv"
 )
 
 // Section represents a line as produced by `go test`
@@ -14,31 +15,68 @@ type Section struct {
 	endLine   int
 	endChar   int
 	sortValue int
+	numStmt   int
 	callCount int
 }
 
+var profileLine = regexp.MustCompile(`^(.+):(\d+)\.(\d+),(\d+)\.(\d+) (\d+)(?: (\d+))?$`)
+
+// what untested and total are counted in
+const unitSections = "sections"
+const unitStatements = "statements" // weighs sections by size, like the percentage `go test -cover` prints
+const unitLines = "lines"           // only for percentages, untested sections per line of source like go-testcov used to
+
 // NewSection parses a coverage line as produces by `go test`, for example "foo/bar.go:1.2,3.5 1 0"
-func NewSection(line string) Section {
-	// parse which package was covered
-	fileAndLocation := strings.SplitN(line, ":", 2)
-	path := fileAndLocation[0]
-	location := fileAndLocation[1]
-
-	// parse where the coverage starts and ends
-	locations := regexp.MustCompile("[,. ]").Split(location, -1)
-	startLine := stringToInt(locations[0])
-	startChar := stringToInt(locations[1])
-	endLine := stringToInt(locations[2])
-	endChar := stringToInt(locations[3])
+func NewSection(line string) (section Section, err error) {
+	match := profileLine.FindStringSubmatch(line)
+	if match == nil {
+		return section, fmt.Errorf("expected `file:line.column,line.column statements count` but got %q", line)
+	}
+
+	// parse where the coverage starts and ends, the regex makes sure they are numbers so only overflows fail
+	numbers := []int{}
+	for _, number := range match[2:] {
+		converted, err := strconv.Atoi(number)
+		if number != "" && err != nil {
+			return section, err
+		}
+		numbers = append(numbers, converted)
+	}
+	startLine, startChar, endLine, endChar := numbers[0], numbers[1], numbers[2], numbers[3]
 
 	// allow sorting multiple sections from the same path
 	sortValue := startLine*100000 + startChar
 
-	callCount := stringToInt(locations[len(locations)-1])
+	numStmt, callCount := numbers[4], numbers[5]
+	if match[7] == "" {
+		numStmt, callCount = 1, numbers[4] // hand-written profiles sometimes leave it out
+	}
 
-	return Section{path, startLine, startChar, endLine, endChar, sortValue, callCount}
+	return Section{match[1], startLine, startChar, endLine, endChar, sortValue, numStmt, callCount}, nil
 }
 
 func (s Section) Location() string {
 	return fmt.Sprintf("%v.%v,%v.%v", s.startLine, s.startChar, s.endLine, s.endChar)
 }
+
+// MarshalJSON shows where the section is, the call count is implied by the report it is in
+func (s Section) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"location":  s.Location(),
+		"startLine": s.startLine,
+		"startChar": s.startChar,
+		"endLine":   s.endLine,
+		"endChar":   s.endChar,
+	})
+}
+
+// how many sections or statements there are in the given sections
+func countIn(sections []Section, unit string) (count int) {
+	if unit != unitStatements {
+		return len(sections)
+	}
+	for _, section := range sections {
+		count += section.numStmt
+	}
+	return
+}
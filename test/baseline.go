@@ -0,0 +1 @@
+../baseline.go
\ No newline at end of file
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("baseline", func() {
+	Describe("readBaseline", func() {
+		It("is empty when missing", func() {
+			inTempDir(func() {
+				Expect(readBaseline(baselineFileName)).To(Equal(Baseline{}))
+			})
+		})
+
+		It("reads counts and where they are configured", func() {
+			inTempDir(func() {
+				writeFile(baselineFileName, "{\n  \"a/b.go\": 2,\n  \"c.go\": 1\n}\n")
+				Expect(readBaseline(baselineFileName)).To(Equal(Baseline{
+					path:     baselineFileName,
+					untested: map[string]int{"a/b.go": 2, "c.go": 1},
+					lines:    map[string]int{"a/b.go": 2, "c.go": 3},
+				}))
+			})
+		})
+
+		It("fails on invalid json", func() {
+			inTempDir(func() {
+				writeFile(baselineFileName, "{")
+				_, err := readBaseline(baselineFileName)
+				Expect(err).To(MatchError(baselineFileName + ": unexpected end of JSON input"))
+			})
+		})
+
+		It("fails when it cannot be read", func() {
+			inTempDir(func() {
+				noError(os.Mkdir(baselineFileName, 0755))
+				_, err := readBaseline(baselineFileName)
+				Expect(err).To(MatchError(HaveSuffix("is a directory")))
+			})
+		})
+	})
+
+	Describe("untestedForFile", func() {
+		baseline := Baseline{path: baselineFileName, untested: map[string]int{"a/b.go": 2}, lines: map[string]int{"a/b.go": 2}}
+
+		It("finds files relative to the working directory", func() {
+			count, line, found := baseline.untestedForFile("/work/a/b.go", "/work")
+			Expect([]interface{}{count, line, found}).To(Equal([]interface{}{2, 2, true}))
+		})
+
+		It("does not find unknown files", func() {
+			_, _, found := baseline.untestedForFile("c.go", "/work")
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	Describe("writeBaseline", func() {
+		It("writes untested counts of files that are not configured otherwise", func() {
+			inTempDir(func() {
+				stderr := captureStderr(func() {
+					noError(writeBaseline(baselineFileName, Report{Files: []FileReport{
+						{ReadPath: "b.go", Budget: Budget{ActualUntested: 2}},
+						{ReadPath: "a.go", Budget: Budget{ActualUntested: 1}},
+						{ReadPath: "tested.go", Budget: Budget{ActualUntested: 0}},
+						{ReadPath: "configured.go", Budget: Budget{ActualUntested: 3, ConfiguredIn: "configured.go"}},
+					}}, "/work"))
+				})
+				Expect(stderr).To(Equal("go-testcov: wrote 2 files with untested sections to " + baselineFileName + "\n"))
+				Expect(readFile(baselineFileName)).To(Equal("{\n  \"a.go\": 1,\n  \"b.go\": 2\n}\n"))
+			})
+		})
+
+		It("fails with a config error when it cannot be written", func() {
+			err := writeBaseline(joinPath("missing", baselineFileName), Report{}, "/work")
+			Expect(errors.As(err, &ConfigError{})).To(BeTrue())
+			Expect(err.Error()).To(HavePrefix("baseline could not be written: open missing"))
+		})
+	})
+})
@@ -0,0 +1 @@
+../color.go
\ No newline at end of file
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func withTerminal(fn func()) {
+	isTerminal = func(*os.File) bool { return true }
+	defer func() { isTerminal = originalIsTerminal }()
+	withoutEnv("NO_COLOR", fn)
+}
+
+var originalIsTerminal = isTerminal
+
+var _ = Describe("color", func() {
+	Describe("isTerminal", func() {
+		It("is true for devices", func() {
+			file, err := os.Open("/dev/zero")
+			noError(err)
+			defer file.Close()
+			Expect(isTerminal(file)).To(BeTrue())
+		})
+
+		It("is false for the null device", func() {
+			file, err := os.Open(os.DevNull)
+			noError(err)
+			defer file.Close()
+			Expect(isTerminal(file)).To(BeFalse())
+		})
+
+		It("is false for files", func() {
+			withTempFile("", func(file *os.File) {
+				Expect(isTerminal(file)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("useColor", func() {
+		It("colors in a terminal", func() {
+			withTerminal(func() {
+				Expect(useColor(false)).To(BeTrue())
+			})
+		})
+
+		It("does not color outside of a terminal", func() {
+			Expect(useColor(false)).To(BeFalse())
+		})
+
+		It("does not color with --no-color", func() {
+			withTerminal(func() {
+				Expect(useColor(true)).To(BeFalse())
+			})
+		})
+
+		It("does not color with NO_COLOR", func() {
+			withTerminal(func() {
+				withEnv("NO_COLOR", "1", func() {
+					Expect(useColor(false)).To(BeFalse())
+				})
+			})
+		})
+	})
+
+	Describe("colorize", func() {
+		It("colors", func() {
+			Expect(colorize(true, colorRed, "foo")).To(Equal("\033[31mfoo\033[0m"))
+		})
+
+		It("does not color when disabled", func() {
+			Expect(colorize(false, colorRed, "foo")).To(Equal("foo"))
+		})
+	})
+
+	Describe("sourceContext", func() {
+		content := "package foo\nfunc foo() {\n\tbar()\n}\n"
+
+		It("highlights a single line", func() {
+			Expect(sourceContext(content, Section{startLine: 3, startChar: 2, endLine: 3, endChar: 7})).To(Equal(
+				"\033[2m    3 |\033[0m \t\033[31mbar()\033[0m\n",
+			))
+		})
+
+		It("highlights multiple lines", func() {
+			Expect(sourceContext(content, Section{startLine: 2, startChar: 12, endLine: 4, endChar: 2})).To(Equal(
+				"\033[2m    2 |\033[0m func foo() \033[31m{\033[0m\n" +
+					"\033[2m    3 |\033[0m \033[31m\tbar()\033[0m\n" +
+					"\033[2m    4 |\033[0m \033[31m}\033[0m\n",
+			))
+		})
+
+		It("does not fail when the file changed since the profile was written", func() {
+			Expect(sourceContext("a\nb", Section{startLine: 2, startChar: 5, endLine: 9, endChar: 2})).To(Equal(
+				"\033[2m    2 |\033[0m b\033[31m\033[0m\n",
+			))
+		})
+	})
+})
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("config", func() {
+	Describe("findConfig", func() {
+		It("finds nothing without config", func() {
+			inTempDir(func() {
+				Expect(findConfig(mustGetwd())).To(Equal(Config{}))
+			})
+		})
+
+		It("finds the config in a parent directory", func() {
+			inTempDir(func() {
+				writeFile(configFileName, "format: json\n")
+				noError(os.MkdirAll("a/b", 0700))
+				config, err := findConfig(filepath.Join(mustGetwd(), "a", "b"))
+				Expect(err).To(BeNil())
+				Expect(config).To(Equal(Config{path: filepath.Join(mustGetwd(), configFileName), format: "json"}))
+			})
+		})
+
+		It("does not leave the module", func() {
+			inTempDir(func() {
+				writeFile(configFileName, "format: json\n")
+				noError(os.MkdirAll("a", 0700))
+				writeFile("a/go.mod", "module a\n")
+				Expect(findConfig(filepath.Join(mustGetwd(), "a"))).To(Equal(Config{}))
+			})
+		})
+
+		It("fails when the config cannot be read", func() {
+			inTempDir(func() {
+				noError(os.Mkdir(configFileName, 0700))
+				_, err := findConfig(mustGetwd())
+				Expect(err).To(MatchError(HaveSuffix("is a directory")))
+			})
+		})
+	})
+
+	Describe("parseConfig", func() {
+		It("parses everything", func() {
+			config, err := parseConfig(
+				"x.yml",
+				"# comment\nformat: 'json' # comment\nflags:\n  - -race\n  - \"-run=#1\"\ngenerated:\n  - \"*.pb.go\"\n\nuntested:\n  \"vendor/**\": ignore\n  legacy/*.go: 5\n  old/*.go: 20%\n",
+			)
+			Expect(err).To(BeNil())
+			Expect(config).To(Equal(Config{
+				path:      "x.yml",
+				format:    "json",
+				flags:     []string{"-race", "-run=#1"},
+				generated: []string{"*.pb.go"},
+				untested: []ConfigUntested{
+					{"vendor/**", 100, true, 10},
+					{"legacy/*.go", 5, false, 11},
+					{"old/*.go", 20, true, 12},
+				},
+			}))
+		})
+
+		It("parses inline flags", func() {
+			config, err := parseConfig("x.yml", "flags: -race  -v\n")
+			Expect(err).To(BeNil())
+			Expect(config.flags).To(Equal([]string{"-race", "-v"}))
+		})
+
+		DescribeTable("fails on invalid config",
+			func(content string, message string) {
+				_, err := parseConfig("x.yml", content)
+				Expect(err).To(MatchError(message))
+			},
+			Entry("no key", "nope\n", "x.yml:1: expected `key: value`"),
+			Entry("unknown key", "nope: 1\n", `x.yml:1: unknown key "nope"`),
+			Entry("unknown format", "format: xml\n", `x.yml:1: unknown format "xml", use one of text, json, github, sarif`),
+			Entry("inline list", "generated: foo\n", "x.yml:1: generated needs to be a nested list"),
+			Entry("not a list", "generated:\n  foo\n", "x.yml:2: expected `- value`"),
+			Entry("not a map", "untested:\n  foo\n", "x.yml:2: expected `glob: count`"),
+			Entry("bad count", "untested:\n  foo: bar\n", `x.yml:2: expected a count, percentage or ignore but got "bar"`),
+			Entry("indented", "  foo: bar\n", "x.yml:1: unexpected indentation"),
+		)
+	})
+
+	Describe("untestedForFile", func() {
+		It("finds nothing without config", func() {
+			_, found := Config{}.untestedForFile("foo.go")
+			Expect(found).To(BeFalse())
+		})
+
+		It("finds the first matching glob relative to the config", func() {
+			inTempDir(func() {
+				config := Config{path: filepath.Join(mustGetwd(), configFileName), untested: []ConfigUntested{
+					{"foo/*.go", 1, false, 1},
+					{"**", 2, false, 2},
+				}}
+				untested, _ := config.untestedForFile("foo/bar.go")
+				Expect(untested).To(Equal(ConfigUntested{"foo/*.go", 1, false, 1}))
+				untested, _ = config.untestedForFile("foo/bar/baz.go")
+				Expect(untested).To(Equal(ConfigUntested{"**", 2, false, 2}))
+			})
+		})
+
+		It("finds nothing when no glob matches", func() {
+			inTempDir(func() {
+				config := Config{path: filepath.Join(mustGetwd(), configFileName), untested: []ConfigUntested{{"foo/*.go", 1, false, 1}}}
+				_, found := config.untestedForFile("bar.go")
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("isGenerated", func() {
+		It("is not generated without config", func() {
+			Expect(Config{}.isGenerated("foo.pb.go")).To(BeFalse())
+		})
+
+		It("matches generated globs", func() {
+			inTempDir(func() {
+				config := Config{path: filepath.Join(mustGetwd(), configFileName), generated: []string{"**/*.pb.go"}}
+				Expect(config.isGenerated("foo.pb.go")).To(BeTrue())
+				Expect(config.isGenerated("a/b/foo.pb.go")).To(BeTrue())
+				Expect(config.isGenerated("foo.go")).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("relativePath", func() {
+		It("is relative to the config", func() {
+			inTempDir(func() {
+				Expect(Config{path: filepath.Join(mustGetwd(), configFileName)}.relativePath(joinPath("a", "b.go"))).To(Equal("a/b.go"))
+			})
+		})
+
+		It("keeps paths that cannot be made relative", func() {
+			Expect(Config{path: configFileName}.relativePath("/a/b.go")).To(Equal("/a/b.go"))
+		})
+	})
+
+	Describe("matchGlob", func() {
+		DescribeTable("matches",
+			func(glob string, path string, expected bool) {
+				Expect(matchGlob(glob, path)).To(Equal(expected))
+			},
+			Entry("exact", "foo.go", "foo.go", true),
+			Entry("star", "*.go", "foo.go", true),
+			Entry("star does not cross directories", "*.go", "a/foo.go", false),
+			Entry("double star crosses directories", "vendor/**", "vendor/a/b.go", true),
+			Entry("double star slash matches no directory", "**/foo.go", "foo.go", true),
+			Entry("double star slash matches directories", "**/foo.go", "a/b/foo.go", true),
+			Entry("question mark", "fo?.go", "foo.go", true),
+			Entry("dot is literal", "foo.go", "fooxgo", false),
+		)
+	})
+
+	Describe("parseConfiguredUntested", func() {
+		It("parses counts, percent and ignore", func() {
+			Expect(parseConfiguredUntested("3")).To(Equal(3))
+			count, percent, _ := parseConfiguredUntested("4%")
+			Expect([]interface{}{count, percent}).To(Equal([]interface{}{4, true}))
+			count, percent, _ = parseConfiguredUntested("ignore")
+			Expect([]interface{}{count, percent}).To(Equal([]interface{}{100, true}))
+		})
+	})
+})
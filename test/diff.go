@@ -0,0 +1 @@
+../diff.go
\ No newline at end of file
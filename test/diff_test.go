@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("diff", func() {
+	Describe("parseDiff", func() {
+		It("returns empty for empty diff", func() {
+			Expect(parseDiff("")).To(Equal(map[string][]LineRange{}))
+		})
+
+		It("parses added and modified lines per file", func() {
+			diff := "diff --git a/foo.go b/foo.go\n" +
+				"--- a/foo.go\n" +
+				"+++ b/foo.go\n" +
+				"@@ -1 +1 @@\n" +
+				"-old\n" +
+				"+new\n" +
+				"@@ -5,0 +6,3 @@\n" +
+				"+a\n+b\n+c\n" +
+				"@@ -10,2 +12,0 @@\n" +
+				"-removed\n-removed\n" +
+				"--- a/bar/baz.go\n" +
+				"+++ b/bar/baz.go\n" +
+				"@@ -3,0 +4,2 @@\n" +
+				"+++ looks like a header\n" +
+				"+x\n"
+			Expect(parseDiff(diff)).To(Equal(map[string][]LineRange{
+				"foo.go":     {{1, 1}, {6, 8}},
+				"bar/baz.go": {{4, 5}},
+			}))
+		})
+
+		It("ignores deleted files", func() {
+			diff := "--- a/foo.go\n+++ /dev/null\n@@ -1,2 +0,0 @@\n-a\n-b\n"
+			Expect(parseDiff(diff)).To(Equal(map[string][]LineRange{}))
+		})
+
+		It("fails on line numbers that are too big", func() {
+			_, err := parseDiff("--- a/foo.go\n+++ b/foo.go\n@@ -1 +99999999999999999999 @@\n")
+			Expect(err).To(MatchError(ContainSubstring(`invalid hunk header "@@ -1 +99999999999999999999 @@": strconv.Atoi`)))
+		})
+
+		It("fails on counts that are too big", func() {
+			_, err := parseDiff("--- a/foo.go\n+++ b/foo.go\n@@ -1 +1,99999999999999999999 @@\n")
+			Expect(err).To(MatchError(ContainSubstring("value out of range")))
+		})
+	})
+
+	Describe("changedLinesForFile", func() {
+		changed := map[string][]LineRange{"foo/bar.go": {{1, 2}}}
+
+		It("finds relative paths", func() {
+			Expect(changedLinesForFile(changed, "foo/bar.go", "/work")).To(Equal([]LineRange{{1, 2}}))
+		})
+
+		It("finds absolute paths inside the working directory", func() {
+			Expect(changedLinesForFile(changed, "/work/foo/bar.go", "/work")).To(Equal([]LineRange{{1, 2}}))
+		})
+
+		It("does not find absolute paths outside the working directory", func() {
+			Expect(changedLinesForFile(changed, "/other/foo/bar.go", "/work")).To(BeNil())
+		})
+	})
+
+	Describe("sectionsInChangedLines", func() {
+		It("keeps sections overlapping changed lines", func() {
+			sections := []Section{
+				{"foo.go", 1, 2, 2, 3, 100002, 1, 0},
+				{"foo.go", 3, 2, 5, 3, 300002, 1, 0},
+				{"foo.go", 7, 2, 7, 3, 700002, 1, 0},
+			}
+			Expect(sectionsInChangedLines(sections, []LineRange{{4, 4}, {7, 9}})).To(Equal([]Section{
+				{"foo.go", 3, 2, 5, 3, 300002, 1, 0},
+				{"foo.go", 7, 2, 7, 3, 700002, 1, 0},
+			}))
+		})
+
+		It("keeps nothing without changes", func() {
+			Expect(sectionsInChangedLines([]Section{{"foo.go", 1, 2, 2, 3, 100002, 1, 0}}, nil)).To(Equal([]Section{}))
+		})
+	})
+
+	Describe("verifyRef", func() {
+		It("passes for commits", func() {
+			withFakeExecutable("git", "echo abc123", func() {
+				noError(verifyRef("main"))
+			})
+		})
+
+		It("fails for unknown refs", func() {
+			withFakeExecutable("git", "echo fatal: Needed a single revision >&2; exit 128", func() {
+				Expect(verifyRef("nope")).To(MatchError(ConfigError{errors.New("--diff=nope is not a commit: fatal: Needed a single revision")}))
+			})
+		})
+	})
+
+	Describe("changedLines", func() {
+		It("fails when git fails", func() {
+			withFakeExecutable("git", "echo fatal: bad revision >&2; exit 128", func() {
+				_, err := changedLines("nope")
+				Expect(err).To(MatchError("--diff=nope could not be compared: fatal: bad revision"))
+			})
+		})
+
+		It("fails when git cannot be run", func() {
+			withEnv("PATH", "", func() {
+				_, err := changedLines("nope")
+				Expect(err).To(MatchError(ContainSubstring("--diff=nope could not be compared: exec: \"git\"")))
+			})
+		})
+
+		It("fails when the diff cannot be parsed", func() {
+			withFakeExecutable("git", "printf -- '--- a/foo\\n+++ b/foo\\n@@ -1 +99999999999999999999 @@\\n'", func() {
+				_, err := changedLines("main")
+				Expect(err).To(MatchError(HavePrefix("--diff=main could not be parsed: invalid hunk header")))
+			})
+		})
+
+		It("reads changes from git", func() {
+			withFakeExecutable("git", "printf -- '--- a/foo\\n+++ b/foo\\n@@ -1 +1 @@\\n'", func() {
+				changed, err := changedLines("main")
+				Expect(err).To(BeNil())
+				Expect(changed).To(Equal(map[string][]LineRange{"foo": {{1, 1}}}))
+			})
+		})
+	})
+})
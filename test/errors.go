@@ -0,0 +1 @@
+../errors.go
\ No newline at end of file
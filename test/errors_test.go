@@ -0,0 +1,75 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("errors", func() {
+	Describe("ProfileError", func() {
+		It("shows the error", func() {
+			Expect(ProfileError{err: errors.New("boom")}.Error()).To(Equal("boom"))
+		})
+
+		It("shows the path", func() {
+			Expect(ProfileError{path: "c.out", err: errors.New("boom")}.Error()).To(Equal("c.out: boom"))
+		})
+
+		It("shows the path and line", func() {
+			Expect(ProfileError{path: "c.out", line: 2, err: errors.New("boom")}.Error()).To(Equal("c.out:2: boom"))
+		})
+
+		It("unwraps", func() {
+			inner := errors.New("boom")
+			Expect(errors.Is(ProfileError{path: "c.out", err: inner}, inner)).To(BeTrue())
+		})
+	})
+
+	Describe("ConfigError", func() {
+		It("unwraps", func() {
+			inner := errors.New("boom")
+			Expect(errors.Is(ConfigError{inner}, inner)).To(BeTrue())
+		})
+	})
+
+	Describe("exitWithError", func() {
+		It("exits with config code for config errors", func() {
+			expectCommand(
+				func() int { return exitWithError(fmt.Errorf("wrapped: %w", ConfigError{errors.New("boom")})) },
+				[]interface{}{exitConfig, "", "go-testcov: wrapped: boom\n"},
+			)
+		})
+
+		It("exits with profile code for other errors", func() {
+			expectCommand(
+				func() int { return exitWithError(ProfileError{path: "c.out", err: errors.New("boom")}) },
+				[]interface{}{exitProfile, "", "go-testcov: c.out: boom\n"},
+			)
+		})
+	})
+
+	Describe("recoverAsError", func() {
+		It("turns panics into an exit code", func() {
+			expectCommand(
+				func() (exitCode int) {
+					defer recoverAsError(&exitCode)
+					panic("boom")
+				},
+				[]interface{}{exitProfile, "", "go-testcov: internal error: boom\n"},
+			)
+		})
+
+		It("does nothing without panic", func() {
+			expectCommand(
+				func() (exitCode int) {
+					defer recoverAsError(&exitCode)
+					return 0
+				},
+				[]interface{}{0, "", ""},
+			)
+		})
+	})
+})
@@ -0,0 +1 @@
+../fix.go
\ No newline at end of file
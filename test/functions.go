@@ -0,0 +1 @@
+../functions.go
\ No newline at end of file
@@ -0,0 +1,48 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("functions", func() {
+	Describe("functionReports", func() {
+		content := "package foo\n\n" +
+			"func a() {\n\tx()\n\tgo func() {\n\t\ty()\n\t}()\n}\n\n" +
+			"func (s *Server) b() {\n\tz()\n}\n\n" +
+			"func (l List[T]) c() {\n\tz()\n}\n\n" +
+			"func d()\n"
+		sections := []Section{
+			{"foo.go", 3, 10, 5, 12, 300010, 2, 1},
+			{"foo.go", 5, 12, 7, 3, 500012, 1, 0},
+			{"foo.go", 10, 22, 12, 2, 1000022, 3, 0},
+			{"foo.go", 14, 22, 16, 2, 1400022, 1, 1},
+		}
+		untested := []Section{sections[1], sections[2]}
+
+		It("counts untested sections by function, most untested first", func() {
+			Expect(functionReports("foo.go", content, sections, untested, unitStatements)).To(Equal([]FunctionReport{
+				{"(*Server).b", 10, 3, 3, 100},
+				{"a", 3, 1, 3, 33},
+			}))
+		})
+
+		It("counts sections", func() {
+			Expect(functionReports("foo.go", content, sections, untested, unitSections)).To(Equal([]FunctionReport{
+				{"a", 3, 1, 2, 50},
+				{"(*Server).b", 10, 1, 1, 100},
+			}))
+		})
+
+		It("names generic methods", func() {
+			untested := []Section{sections[3]}
+			Expect(functionReports("foo.go", content, sections, untested, unitSections)).To(Equal([]FunctionReport{
+				{"(List[T]).c", 14, 1, 1, 100},
+			}))
+		})
+
+		It("skips files that do not parse", func() {
+			Expect(functionReports("foo", "nope", sections, untested, unitSections)).To(BeNil())
+		})
+	})
+})
@@ -0,0 +1 @@
+../ignores.go
\ No newline at end of file
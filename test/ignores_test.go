@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/onsi/ginkgo/extensions/table"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ignores", func() {
+	Describe("findIgnores", func() {
+		It("finds nothing", func() {
+			Expect(findIgnores("foo.go", "package foo\n")).To(BeNil())
+		})
+
+		It("finds all kinds with reasons", func() {
+			content := "// untested sections: 5 legacy parser\n" +
+				"foo() // untested section: network failure path\n" +
+				"\t// untested section random, timing\n" +
+				"\t// untested block - only on windows\n" +
+				"//testcov:ignore debug helper\n"
+			Expect(findIgnores("foo.go", content)).To(Equal([]Ignore{
+				{1, ignoreFile, "legacy parser", "", false, 0, false},
+				{2, ignoreSection, "network failure path", "", false, 0, false},
+				{3, ignoreRandom, "timing", "", false, 0, false},
+				{4, ignoreBlock, "only on windows", "", false, 0, false},
+				{5, ignoreFunction, "debug helper", "", false, 0, false},
+			}))
+		})
+
+		It("finds markers without reasons", func() {
+			content := "// untested sections: 5\nfoo() // untested section\n// untested section random\n// untested block\n//testcov:ignore\n"
+			Expect(findIgnores("foo.go", content)).To(Equal([]Ignore{
+				{1, ignoreFile, "", "", false, 0, false},
+				{2, ignoreSection, "", "", false, 0, false},
+				{3, ignoreRandom, "", "", false, 0, false},
+				{4, ignoreBlock, "", "", false, 0, false},
+				{5, ignoreFunction, "", "", false, 0, false},
+			}))
+		})
+
+		It("only finds markers in comments of go files", func() {
+			content := "package foo\n\nfunc help() string {\n\treturn \"add // untested section: reason\" // untested section: help text\n}\n"
+			Expect(findIgnores("foo.go", content)).To(Equal([]Ignore{
+				{4, ignoreSection, "help text", "", false, 0, false},
+			}))
+		})
+	})
+
+	Describe("expiring", func() {
+		It("finds when markers expire", func() {
+			withNow("2026-06-01", func() {
+				content := "// untested sections: 5 until=2027-01-15\n" +
+					"foo() // untested section: network failure until 2026-05-31\n" +
+					"//testcov:ignore until 2026-06-01, debug helper\n"
+				Expect(findIgnores("foo.go", content)).To(Equal([]Ignore{
+					{1, ignoreFile, "", "2027-01-15", false, 0, false},
+					{2, ignoreSection, "network failure", "2026-05-31", true, 0, false},
+					{3, ignoreFunction, "debug helper", "2026-06-01", false, 0, false},
+				}))
+			})
+		})
+
+		It("fails on invalid dates", func() {
+			_, err := findIgnores("foo.go", "\nfoo() // untested section until 2026-02-31\n")
+			Expect(err).To(MatchError("foo.go:2: expected a date like until=2026-12-31 but got \"2026-02-31\""))
+		})
+
+		It("does not apply expired markers", func() {
+			withNow("2027-01-01", func() {
+				content := "package a\n\n//testcov:ignore until 2026-12-31\nfunc a() {\n\tx() // untested section until 2026-12-31\n}\n\n//testcov:ignore until 2027-01-01\nfunc b() {\n\ty()\n}\n"
+				sections := sectionsFromProfile("a.go:4.10,5.5 1 0\na.go:9.10,10.5 1 0\n")
+				Expect(locations(removeIgnoredSections("a.go", content, sections, sections))).To(Equal([]string{"4.10,5.5"}))
+			})
+		})
+
+		It("does not apply expired markers in files that do not parse", func() {
+			withNow("2027-01-01", func() {
+				content := "a // untested section until 2026-12-31\n// untested block until=2026-12-31\nb {\n c\n}\nd // untested section\n"
+				sections := sectionsFromProfile("a.go:1.1,1.2 1 0\na.go:3.3,4.2 1 0\na.go:6.1,6.2 1 0\n")
+				Expect(locations(removeIgnoredSections("a.go", content, sections, sections))).To(Equal([]string{"1.1,1.2", "3.3,4.2"}))
+			})
+		})
+	})
+
+	Describe("countSuppressed", func() {
+		count := func(file FileReport, content string, profile string) []Ignore {
+			sections := sectionsFromProfile(profile)
+			untested := untestedFromSections(sections)
+			kept := removeIgnoredSections(file.ReadPath, content, sections, untested)
+			file.Ignores, _ = findIgnores("foo.go", content)
+			return countSuppressed(file, content, sections, untested, kept)
+		}
+
+		It("counts what markers in go files excuse", func() {
+			content := "package a\n\nfunc a(x int) {\n\tif x > 1 { // untested section: a\n\t\tx()\n\t}\n\tif x > 2 { // untested section random\n\t\ty()\n\t}\n\tif x > 3 { // untested section\n\t\tz()\n\t}\n}\n"
+			profile := "a.go:5.3,6.1 2 0\na.go:8.3,9.1 1 1\na.go:11.3,12.1 1 1\n"
+			Expect(count(FileReport{ReadPath: "a.go", Budget: Budget{Unit: unitStatements}}, content, profile)).To(Equal([]Ignore{
+				{4, ignoreSection, "a", "", false, 2, false},
+				{7, ignoreRandom, "", "", false, 0, false},
+				{10, ignoreSection, "", "", false, 0, true},
+			}))
+		})
+
+		It("counts what markers excuse in files that do not parse", func() {
+			content := "a // untested section\n// untested block\nb {\n c // untested section\n d\n}\ne // untested section\n"
+			profile := "a.go:1.1,1.2 1 0\na.go:3.3,4.2 1 0\na.go:5.2,5.3 1 0\na.go:7.1,7.2 1 1\n"
+			Expect(count(FileReport{ReadPath: "a.go", Budget: Budget{Unit: unitSections}}, content, profile)).To(Equal([]Ignore{
+				{1, ignoreSection, "", "", false, 1, false},
+				{2, ignoreBlock, "", "", false, 1, false},
+				{4, ignoreSection, "", "", false, 1, false},
+				{7, ignoreSection, "", "", false, 0, true},
+			}))
+		})
+
+		table.DescribeTable("file budgets",
+			func(budget Budget, expected Ignore) {
+				budget.Unit = unitSections
+				budget.ConfiguredIn = "a.go"
+				file := FileReport{ReadPath: "a.go", Budget: budget}
+				Expect(count(file, "// untested sections: x\na\nb\n", "a.go:2.1,2.2 1 0\na.go:3.1,3.2 1 0\n")).To(Equal([]Ignore{expected}))
+			},
+			table.Entry("as configured", Budget{ConfiguredUntested: 2, ConfiguredAtLine: 1}, Ignore{1, ignoreFile, "", "", false, 2, false}),
+			table.Entry("more than configured", Budget{ConfiguredUntested: 1, ConfiguredAtLine: 1}, Ignore{1, ignoreFile, "", "", false, 1, false}),
+			table.Entry("less than configured", Budget{ConfiguredUntested: 3, ConfiguredAtLine: 1}, Ignore{1, ignoreFile, "", "", false, 2, true}),
+			table.Entry("percent", Budget{ConfiguredUntested: 50, Percent: true, ConfiguredAtLine: 1}, Ignore{1, ignoreFile, "", "", false, 2, false}),
+			table.Entry("not what configures the file", Budget{ConfiguredUntested: 3, ConfiguredAtLine: 5}, Ignore{1, ignoreFile, "", "", false, 0, true}),
+		)
+
+		It("marks percent budgets without untested code as stale", func() {
+			file := FileReport{ReadPath: "a.go", Budget: Budget{Unit: unitSections, ConfiguredIn: "a.go", ConfiguredAtLine: 1, ConfiguredUntested: 50, Percent: true}}
+			Expect(count(file, "// untested sections: 50%\na\n", "a.go:2.1,2.2 1 1\n")).To(Equal([]Ignore{{1, ignoreFile, "", "", false, 0, true}}))
+		})
+	})
+
+	Describe("findIgnoreWarnings", func() {
+		warnings := func(content string, profile string) []Warning {
+			sections := sectionsFromProfile(profile)
+			untested := untestedFromSections(sections)
+			file := FileReport{ReadPath: "a.go", Budget: Budget{Unit: unitSections}}
+			file.Ignores, _ = findIgnores("a.go", content)
+			file.Ignores = countSuppressed(file, content, sections, untested, removeIgnoredSections("a.go", content, sections, untested))
+			return findIgnoreWarnings(file, content, sections)
+		}
+
+		It("warns about markers that excuse nothing", func() {
+			content := "package a\n\nfunc a(x int) {\n\tx() // untested section\n\t// untested section\n\ty()\n\tif x > 1 { // untested section\n\t\tz()\n\t}\n}\n"
+			Expect(warnings(content, "a.go:3.16,7.11 3 1\na.go:7.11,9.3 1 0\n")).To(Equal([]Warning{
+				{4, "has `// untested section` but is tested", ruleTestedIgnore},
+				{5, "has `// untested section` but the code below is tested", ruleTestedIgnore},
+			}))
+		})
+
+		It("does not warn about markers that excuse part of what they apply to", func() {
+			content := "package a\n\nfunc A(x int) int { // untested section\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n"
+			Expect(warnings(content, "a.go:3.19,4.11 1 1\na.go:4.11,6.3 1 0\na.go:7.2,7.10 1 1\n")).To(Equal([]Warning{}))
+		})
+
+		It("does not warn about random, block and expired markers", func() {
+			content := "package a\n\nfunc a() {\n\tx() // untested section random\n\t// untested block\n\ty()\n\tz() // untested section until 2020-01-01\n}\n"
+			Expect(warnings(content, "a.go:3.10,7.6 3 1\n")).To(Equal([]Warning{}))
+		})
+
+		It("warns about markers that are not on or above code", func() {
+			content := "package a\n\nfunc a() {\n\tx()\n\n\t// untested section\n\n\t//testcov:ignore\n\ty(\"// untested section\")\n}\n"
+			Expect(warnings(content, "a.go:3.10,9.26 2 0\n")).To(Equal([]Warning{
+				{6, "has an ignore marker that is not on or above code, so it does not apply", ruleMisplacedIgnore},
+				{8, "has an ignore marker that is not on or above code, so it does not apply", ruleMisplacedIgnore},
+			}))
+		})
+
+		It("uses line rules for files that do not parse", func() {
+			Expect(warnings("a {\n// untested section\nb\n", "a.go:3.1,3.2 1 1\n")).To(Equal([]Warning{
+				{2, "has `// untested section` but the code below is tested", ruleTestedIgnore},
+			}))
+		})
+	})
+
+	Describe("findMissingReasons", func() {
+		It("finds nothing with reasons", func() {
+			Expect(findMissingReasons([]Ignore{{1, ignoreSection, "why", "", false, 0, false}})).To(Equal([]Warning{}))
+		})
+
+		It("finds every marker without reason", func() {
+			Expect(findMissingReasons([]Ignore{
+				{1, ignoreSection, "", "", false, 0, false},
+				{2, ignoreSection, "why", "", false, 0, false},
+				{3, ignoreBlock, "", "", false, 0, false},
+			})).To(Equal([]Warning{
+				{1, "has a section marker without reason, --strict needs it after the marker like `: why it is untested`", ruleMissingReason},
+				{3, "has a block marker without reason, --strict needs it after the marker like `: why it is untested`", ruleMissingReason},
+			}))
+		})
+	})
+
+	Describe("writeIgnores", func() {
+		It("writes a table", func() {
+			out := bytes.Buffer{}
+			writeIgnores(&out, Report{Files: []FileReport{
+				{DisplayPath: "foo.go", Budget: Budget{Unit: unitStatements}, Ignores: []Ignore{{1, ignoreFile, "legacy", "", false, 3, false}, {12, ignoreSection, "", "", false, 0, true}}},
+				{DisplayPath: "bar/baz.go"},
+			}})
+			Expect(out.String()).To(Equal(
+				"LOCATION   KIND     REASON  SUPPRESSED    STALE\n" +
+					"foo.go:1   file     legacy  3 statements  no\n" +
+					"foo.go:12  section  -       0 statements  yes\n",
+			))
+		})
+	})
+})
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -44,6 +47,137 @@ var _ = Describe("go-testcov", func() {
 				)
 			})
 		})
+		It("checks profiles", func() {
+			inTempDir(func() {
+				exitCode := -1
+				exitFunction = func(got int) { exitCode = got }
+				defer func() { exitFunction = os.Exit }()
+
+				writeFile("foo", "bar\n")
+				writeFile("shard.out", "mode: set\nfoo:1.2,1.3 1 1\n")
+				withOsArgs([]string{"executable-name", "check", "shard.out"}, func() {
+					expectCommand(
+						func() int {
+							main()
+							return exitCode
+						},
+						[]interface{}{0, "", ""},
+					)
+				})
+			})
+		})
+	})
+
+	Describe("checkProfiles", func() {
+		withShards := func(fn func()) {
+			inTempDir(func() {
+				writeFile("foo", "bar\nbaz\n")
+				writeFile("shard1.out", "mode: count\nfoo:1.2,1.3 1 1\nfoo:2.2,2.3 1 0\n")
+				writeFile("shard2.out", "mode: count\nfoo:1.2,1.3 1 0\nfoo:2.2,2.3 1 0\n")
+				fn()
+			})
+		}
+
+		It("checks merged profiles", func() {
+			withShards(func() {
+				expectCommand(
+					func() int { return checkProfiles([]string{"shard1.out", "shard2.out"}) },
+					[]interface{}{3, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:2.2,2.3\n"},
+				)
+			})
+		})
+
+		It("checks a profile passed with --profile", func() {
+			withShards(func() {
+				expectCommand(
+					func() int { return checkProfiles([]string{"--profile", "shard1.out", "--profile=shard2.out"}) },
+					[]interface{}{3, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:2.2,2.3\n"},
+				)
+			})
+		})
+
+		It("checks binary coverage merged with profiles", func() {
+			covdata := `for arg in "$@"; do case $arg in -o=*) out=${arg#-o=};; esac; done; echo go "$@" >&2; printf 'mode: count\nfoo:2.2,2.3 1 4\n' > $out`
+			withFakeGo(covdata, func() {
+				writeFile("foo", "bar\nbaz\n")
+				writeFile("shard1.out", "mode: count\nfoo:1.2,1.3 1 1\nfoo:2.2,2.3 1 0\n")
+				exitCode := -1
+				stderr := captureStderr(func() {
+					exitCode = checkProfiles([]string{"--covdir", "e2e", "--covdir=cli", "shard1.out"})
+				})
+				Expect(exitCode).To(Equal(0))
+				Expect(stderr).To(MatchRegexp(`^go tool covdata textfmt -i=e2e,cli -o=\S+go-testcov-covdata-\d+\.out\n$`))
+			})
+		})
+
+		It("fails when binary coverage cannot be converted", func() {
+			withFakeGo("exit 3", func() {
+				expectCommand(
+					func() int { return checkProfiles([]string{"--covdir=e2e"}) },
+					[]interface{}{5, "", "go-testcov: go tool covdata failed with exit code 3\n"},
+				)
+			})
+		})
+
+		It("uses options", func() {
+			withShards(func() {
+				expectCommand(
+					func() int { return checkProfiles([]string{"--format=github", "shard2.out"}) },
+					[]interface{}{
+						3,
+						"::error file=foo,line=1,endLine=1,col=2,endColumn=3,title=go-testcov::new untested section introduced (2 current vs 0 configured)\n" +
+							"::error file=foo,line=2,endLine=2,col=2,endColumn=3,title=go-testcov::new untested section introduced (2 current vs 0 configured)\n",
+						"",
+					},
+				)
+			})
+		})
+
+		It("fails without profiles", func() {
+			inTempDir(func() {
+				expectCommand(
+					func() int { return checkProfiles([]string{"--verbose"}) },
+					[]interface{}{4, "", "go-testcov: check needs a coverage profile, like `go-testcov check --profile coverage.out` or `--covdir=dir`\n"},
+				)
+			})
+		})
+
+		It("fails with go test flags", func() {
+			withShards(func() {
+				expectCommand(
+					func() int { return checkProfiles([]string{"-race", "shard1.out"}) },
+					[]interface{}{4, "", "go-testcov: check does not run tests, so -race is not supported\n"},
+				)
+			})
+		})
+
+		It("fails on invalid options", func() {
+			withShards(func() {
+				expectCommand(
+					func() int { return checkProfiles([]string{"--format=nope", "shard1.out"}) },
+					[]interface{}{4, "", "go-testcov: unknown --format \"nope\", use one of text, json, github, sarif\n"},
+				)
+			})
+		})
+
+		It("fails on malformed profiles", func() {
+			withShards(func() {
+				writeFile("bad.out", "mode: set\nfoo:1.2 1\n")
+				expectCommand(
+					func() int { return checkProfiles([]string{"shard1.out", "bad.out"}) },
+					[]interface{}{5, "", "go-testcov: bad.out:2: expected `file:line.column,line.column statements count` but got \"foo:1.2 1\"\n"},
+				)
+			})
+		})
+
+		It("fails on missing profiles", func() {
+			withShards(func() {
+				expectCommand(
+					func() int { return checkProfiles([]string{"shard1.out", "nope.out"}) },
+					[]interface{}{5, "", "go-testcov: open nope.out: no such file or directory\n"},
+				)
+			})
+		})
 	})
 
 	// TODO: use AroundEach to run everything inside of a tempdir https://github.com/onsi/ginkgo/issues/481
@@ -109,19 +243,204 @@ var _ = Describe("go-testcov", func() {
 					writeFile(joinPath(goPath, "src", "foo"), "")
 					expectCommand(
 						runGoTestWithCoverage,
-						[]interface{}{1, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:1.2,1.3\n"},
+						[]interface{}{3, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:1.2,1.3\n"},
+					)
+				})
+			})
+		})
+
+		It("shows the untested code in color in a terminal", func() {
+			withFakeGo("echo header > coverage.out; echo foo:2.2,2.5 0 >> coverage.out; echo foo:3.1,3.2 1 >> coverage.out", func() {
+				writeFile("foo", "\n\tfoo()\n} // untested section\n")
+				withTerminal(func() {
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{3, "", "\033[33mgo-testcov (warn):\033[0m foo:3 has `// untested section` but is tested\n" +
+							"\033[31mfoo new untested sections introduced (1 current vs 0 configured)\033[0m\n" +
+							"foo:2.2,2.5\n" +
+							"\033[2m    2 |\033[0m \t\033[31mfoo\033[0m()\n"},
+					)
+				})
+			})
+		})
+
+		It("does not color with --no-color", func() {
+			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out", func() {
+				writeFile("foo", "")
+				withTerminal(func() {
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--no-color", "./..."}) },
+						[]interface{}{3, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:1.2,1.3\n"},
+					)
+				})
+			})
+		})
+
+		It("shows which functions are untested", func() {
+			withFakeGo("echo header > coverage.out; echo foo.go:3.10,5.2 0 >> coverage.out; echo foo.go:7.21,9.2 1 >> coverage.out", func() {
+				writeFile("foo.go", "package foo\n\nfunc a() {\n\tx()\n}\n\nfunc (s *Server) b() {\n\ty()\n}\n")
+				expectCommand(
+					runGoTestWithCoverage,
+					[]interface{}{3, "", "foo.go new untested sections introduced (1 current vs 0 configured)\nfoo.go:3.10,5.2\nfoo.go:3 a: 1 untested sections (100% of 1)\n"},
+				)
+			})
+		})
+
+		It("fails on every marker without reason when strict", func() {
+			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out; echo foo:3.2,3.3 0 >> coverage.out; echo foo:4.2,4.3 0 >> coverage.out", func() {
+				writeFile("foo", "foo // untested section\n// untested section\nbar\nbaz\n")
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--strict", "./..."}) },
+					[]interface{}{3, "", "" +
+						"go-testcov (error): foo:1 has a section marker without reason, --strict needs it after the marker like `: why it is untested`\n" +
+						"go-testcov (error): foo:2 has a section marker without reason, --strict needs it after the marker like `: why it is untested`\n" +
+						"foo new untested sections introduced (1 current vs 0 configured)\nfoo:4.2,4.3\n"},
+				)
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--strict", "--format=github", "./..."}) },
+					[]interface{}{3, "" +
+						"::error file=foo,line=1,title=go-testcov::has a section marker without reason, --strict needs it after the marker like `: why it is untested`\n" +
+						"::error file=foo,line=2,title=go-testcov::has a section marker without reason, --strict needs it after the marker like `: why it is untested`\n" +
+						"::error file=foo,line=4,endLine=4,col=2,endColumn=3,title=go-testcov::new untested section introduced (1 current vs 0 configured)\n", ""},
+				)
+			})
+		})
+
+		It("fails on markers without reason when strict even when everything else passes", func() {
+			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out", func() {
+				writeFile("foo", "foo // untested section\n")
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--strict", "./..."}) },
+					[]interface{}{3, "", "go-testcov (error): foo:1 has a section marker without reason, --strict needs it after the marker like `: why it is untested`\n"},
+				)
+			})
+		})
+
+		It("passes on markers with reason when strict", func() {
+			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out", func() {
+				writeFile("foo", "foo // untested section: needs a real server\n")
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--strict", "./..."}) },
+					[]interface{}{0, "", ""},
+				)
+			})
+		})
+
+		It("lists ignores instead of checking", func() {
+			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out; echo foo:2.2,2.3 1 >> coverage.out; echo foo:3.2,3.3 0 >> coverage.out", func() {
+				writeFile("foo", "foo // untested section: needs a real server\nbar // untested section\nbaz\n")
+				expectCommand(
+					func() int { return runSubcommand([]string{"ignores", "./..."}) },
+					[]interface{}{0, "" +
+						"LOCATION  KIND     REASON               SUPPRESSED  STALE\n" +
+						"foo:1     section  needs a real server  1 sections  no\n" +
+						"foo:2     section  -                    0 sections  yes\n", ""},
+				)
+			})
+		})
+
+		Context("go files", func() {
+			withGoFile := func(content string, fn func()) {
+				withFakeGo("echo header > coverage.out; echo a.go:3.19,4.11 1 1 >> coverage.out; echo a.go:4.11,6.3 1 0 >> coverage.out; echo a.go:7.2,7.10 1 1 >> coverage.out", func() {
+					writeFile("a.go", content)
+					fn()
+				})
+			}
+
+			It("agrees on what a marker applies to when checking, listing and fixing", func() {
+				content := "package a\n\nfunc A(x int) int { // untested section\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n"
+				withGoFile(content, func() {
+					expectCommand(runGoTestWithCoverage, []interface{}{0, "", ""})
+					expectCommand(
+						func() int { return runSubcommand([]string{"ignores", "./..."}) },
+						[]interface{}{0, "" +
+							"LOCATION  KIND     REASON  SUPPRESSED  STALE\n" +
+							"a.go:3    section  -       1 sections  no\n", ""},
+					)
+					expectCommand(func() int { return runGoTestAndCheckCoverage([]string{"--fix", "./..."}) }, []interface{}{0, "", ""})
+					Expect(readFile("a.go")).To(Equal(content))
+				})
+			})
+
+			It("keeps markers in the doc comment of a partially tested function", func() {
+				content := "package a\n\n// A is old\n// untested section: legacy\nfunc A(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n}\n"
+				withFakeGo("echo header > coverage.out; echo a.go:5.19,6.11 1 1 >> coverage.out; echo a.go:6.11,8.3 1 0 >> coverage.out; echo a.go:9.2,9.10 1 1 >> coverage.out", func() {
+					writeFile("a.go", content)
+					expectCommand(func() int { return runGoTestAndCheckCoverage([]string{"--fix", "./..."}) }, []interface{}{0, "", ""})
+					Expect(readFile("a.go")).To(Equal(content))
+					expectCommand(
+						func() int { return runSubcommand([]string{"ignores", "./..."}) },
+						[]interface{}{0, "" +
+							"LOCATION  KIND     REASON  SUPPRESSED  STALE\n" +
+							"a.go:4    section  legacy  1 sections  no\n", ""},
+					)
+				})
+			})
+
+			It("warns about markers that do not apply to code", func() {
+				withGoFile("package a\n\nfunc A(x int) int {\n\tif x > 0 {\n\t\treturn 1\n\t}\n\treturn 0\n\n\t// untested section\n}\n", func() {
+					expectCommand(runGoTestWithCoverage, []interface{}{3, "", "" +
+						"go-testcov (warn): a.go:9 has an ignore marker that is not on or above code, so it does not apply\n" +
+						"a.go new untested sections introduced (1 current vs 0 configured)\na.go:4.11,6.3\na.go:3 A: 1 untested sections (33% of 3)\n"})
+				})
+			})
+
+			It("warns about and fixes markers on tested code", func() {
+				withGoFile("package a\n\nfunc A(x int) int {\n\tif x > 0 { // untested section\n\t\treturn 1\n\t}\n\treturn 0 // untested section\n}\n", func() {
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{0, "", "go-testcov (warn): a.go:7 has `// untested section` but is tested\n"},
+					)
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--fix", "./..."}) },
+						[]interface{}{0, "", "go-testcov (fix): a.go:7 removed tested `// untested section`\n"},
+					)
+					Expect(readFile("a.go")).To(Equal("package a\n\nfunc A(x int) int {\n\tif x > 0 { // untested section\n\t\treturn 1\n\t}\n\treturn 0\n}\n"))
+				})
+			})
+		})
+
+		It("fails when a configured untested comment expired", func() {
+			withFakeGo("echo header > coverage.out; echo foo:2.2,2.3 0 >> coverage.out", func() {
+				writeFile("foo", "// untested sections: 1 until=2026-12-31\nbar\n")
+				withNow("2027-01-01", func() {
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{3, "", "go-testcov (warn): foo:1 has an ignore marker that expired on 2026-12-31\nfoo new untested sections introduced (1 current vs 0 configured)\nfoo:2.2,2.3\n"},
 					)
 				})
+				withNow("2026-12-31", func() {
+					expectCommand(runGoTestWithCoverage, []interface{}{0, "", ""})
+				})
+			})
+		})
+
+		It("fails on invalid expiry dates", func() {
+			withFakeGo("echo header > coverage.out; echo foo:2.2,2.3 0 >> coverage.out", func() {
+				writeFile("foo", "bar\nbaz // untested section until 2026-13-01\n")
+				expectCommand(
+					runGoTestWithCoverage,
+					[]interface{}{4, "", "go-testcov: foo:2: expected a date like until=2026-12-31 but got \"2026-13-01\"\n"},
+				)
 			})
 		})
 
 		It("does not show generated files when failing", func() {
-			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out; echo generated.go:1.21.3 0 >> coverage.out", func() {
+			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out; echo generated.go:1.2,1.3 0 >> coverage.out", func() {
 				writeFile("foo", "")
 				writeFile("generated.go", "")
 				expectCommand(
 					runGoTestWithCoverage,
-					[]interface{}{1, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:1.2,1.3\n"},
+					[]interface{}{3, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:1.2,1.3\n"},
+				)
+			})
+		})
+
+		It("fails when covered files cannot be read", func() {
+			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out", func() {
+				expectCommand(
+					runGoTestWithCoverage,
+					[]interface{}{5, "", "go-testcov: foo: covered file could not be read: open foo: no such file or directory\n"},
 				)
 			})
 		})
@@ -136,13 +455,32 @@ var _ = Describe("go-testcov", func() {
 			})
 		})
 
+		It("ignores files with a generated header", func() {
+			withFakeGo("echo header > coverage.out; echo foo.pb.go:3.2,3.3 0 >> coverage.out", func() {
+				writeFile("foo.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage foo\n")
+				expectCommand(
+					runGoTestWithCoverage,
+					[]interface{}{0, "", ""},
+				)
+			})
+		})
+
+		It("explains skipped generated files when verbose", func() {
+			withFakeGo("echo header > coverage.out; echo generated.go:1.2,1.3 0 >> coverage.out", func() {
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--verbose", "./..."}) },
+					[]interface{}{0, "", "go-testcov: skipping generated file generated.go\n"},
+				)
+			})
+		})
+
 		It("fails when configured untested is below actual untested", func() {
 			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out; echo foo:2.2,2.3 0 >> coverage.out", func() {
 				withFakeGoPath(func(goPath string) {
 					writeFile(joinPath(goPath, "src", "foo"), "// untested sections: 1\n")
 					expectCommand(
 						runGoTestWithCoverage,
-						[]interface{}{1, "", "foo new untested sections introduced (2 current vs 1 configured)\nfoo:1.2,1.3\nfoo:2.2,2.3\n"},
+						[]interface{}{3, "", "foo new untested sections introduced (2 current vs 1 configured)\nfoo:1.2,1.3\nfoo:2.2,2.3\n"},
 					)
 				})
 			})
@@ -152,7 +490,7 @@ var _ = Describe("go-testcov", func() {
 			withFailingTestInGoPath(func() {
 				expectCommand(
 					runGoTestWithCoverage,
-					[]interface{}{1, "", "foo2.go new untested sections introduced (1 current vs 0 configured)\nfoo2.go:1.2,1.3\n"},
+					[]interface{}{3, "", "foo2.go new untested sections introduced (1 current vs 0 configured)\nfoo2.go:1.2,1.3\n"},
 				)
 			})
 		})
@@ -164,7 +502,7 @@ var _ = Describe("go-testcov", func() {
 				chDir(other, func() {
 					expectCommand(
 						runGoTestWithCoverage,
-						[]interface{}{1, "", "foo.com/bar/baz/foo2.go new untested sections introduced (1 current vs 0 configured)\nfoo.com/bar/baz/foo2.go:1.2,1.3\n"},
+						[]interface{}{3, "", "foo.com/bar/baz/foo2.go new untested sections introduced (1 current vs 0 configured)\nfoo.com/bar/baz/foo2.go:1.2,1.3\n"},
 					)
 				})
 			})
@@ -177,7 +515,7 @@ var _ = Describe("go-testcov", func() {
 					writeFile(joinPath(goPath, "src", "bar"), "")
 					expectCommand(
 						runGoTestWithCoverage,
-						[]interface{}{1, "", "bar new untested sections introduced (1 current vs 0 configured)\nbar:1.2,1.3\nfoo new untested sections introduced (2 current vs 1 configured)\nfoo:1.2,1.3\nfoo:2.2,2.3\n"},
+						[]interface{}{3, "", "bar new untested sections introduced (1 current vs 0 configured)\nbar:1.2,1.3\nfoo new untested sections introduced (2 current vs 1 configured)\nfoo:1.2,1.3\nfoo:2.2,2.3\n"},
 					)
 				})
 			})
@@ -190,7 +528,7 @@ var _ = Describe("go-testcov", func() {
 					writeFile(joinPath(goPath, "src", "bar"), "")
 					expectCommand(
 						runGoTestWithCoverage,
-						[]interface{}{1, "", "foo new untested sections introduced (2 current vs 1 configured)\nfoo:1.2,1.3\nfoo:2.2,2.3\n"},
+						[]interface{}{3, "", "foo new untested sections introduced (2 current vs 1 configured)\nfoo:1.2,1.3\nfoo:2.2,2.3\n"},
 					)
 				})
 			})
@@ -307,15 +645,40 @@ var _ = Describe("go-testcov", func() {
 					expectCommand(
 						runGoTestWithCoverage,
 						[]interface{}{
-							1,
+							3,
+							"",
+							"foo new untested sections introduced (100% current vs 1% configured, 2 of 2 statements untested)\nfoo:1.2,1.3\nfoo:2.2,2.3\n",
+						},
+					)
+				})
+			})
+		})
+
+		It("fails when configured untested % of lines is below actual untested", func() {
+			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 1 0 >> coverage.out; echo foo:2.2,2.3 1 0 >> coverage.out", func() {
+				withFakeGoPath(func(goPath string) {
+					writeFile(joinPath(goPath, "src", "foo"), "// untested sections: 30%\n\n\n\n\n")
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--percent-of=lines", "./..."}) },
+						[]interface{}{
+							3,
 							"",
-							"foo new untested sections introduced (100% current vs 1% configured)\nfoo:1.2,1.3\nfoo:2.2,2.3\n",
+							"foo new untested sections introduced (33% current vs 30% configured, 2 untested sections in 6 lines)\nfoo:1.2,1.3\nfoo:2.2,2.3\n",
 						},
 					)
 				})
 			})
 		})
 
+		It("passes when configured untested % of statements is above actual untested", func() {
+			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 1 0 >> coverage.out; echo foo:2.2,2.3 3 1 >> coverage.out", func() {
+				withFakeGoPath(func(goPath string) {
+					writeFile(joinPath(goPath, "src", "foo"), "// untested sections: 25%\n\n")
+					expectCommand(runGoTestWithCoverage, []interface{}{0, "", ""})
+				})
+			})
+		})
+
 		It("passes when configured untested % is above actual untested", func() {
 			withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out; echo foo:2.2,2.3 0 >> coverage.out", func() {
 				withFakeGoPath(func(goPath string) {
@@ -384,6 +747,34 @@ var _ = Describe("go-testcov", func() {
 			})
 		})
 
+		It("finds files of all modules in a go.work", func() {
+			withFakeGo("echo header > coverage.out; echo example.com/x/foo.go:1.2,1.3 0 >> coverage.out; echo gopkg.in/yaml.v3/sub/bar.go:1.2,1.3 0 >> coverage.out", func() {
+				writeFile("go.work", "use (\n\t./x\n\t./yaml\n)\n")
+				os.Mkdir("x", 0700)
+				writeFile(joinPath("x", "go.mod"), "module example.com/x\n")
+				writeFile(joinPath("x", "foo.go"), "// untested sections: 1\n")
+				os.MkdirAll(joinPath("yaml", "sub"), 0700)
+				writeFile(joinPath("yaml", "go.mod"), "module gopkg.in/yaml.v3\n")
+				writeFile(joinPath("yaml", "sub", "bar.go"), "")
+				chDir("yaml", func() {
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{3, "", "yaml/sub/bar.go new untested sections introduced (1 current vs 0 configured)\nyaml/sub/bar.go:1.2,1.3\n"},
+					)
+				})
+			})
+		})
+
+		It("fails when the go.work cannot be read", func() {
+			withFakeGo("echo header > coverage.out; echo example.com/x/foo.go:1.2,1.3 0 >> coverage.out", func() {
+				noError(os.Mkdir("go.work", 0700))
+				expectCommand(
+					runGoTestWithCoverage,
+					[]interface{}{4, "", "go-testcov: read " + joinPath(mustGetwd(), "go.work") + ": is a directory\n"},
+				)
+			})
+		})
+
 		It("cleans up coverage.out", func() {
 			withFakeGo("touch coverage.out\necho 1", func() {
 				expectCommand(
@@ -406,6 +797,29 @@ var _ = Describe("go-testcov", func() {
 			})
 		})
 
+		It("uses and keeps the coverage profile the user asked for", func() {
+			withFakeGo("touch cov.out\necho go \"$@\"", func() {
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"-coverprofile", "cov.out", "./..."}) },
+					[]interface{}{0, "go test -coverprofile cov.out ./...\n", ""},
+				)
+				_, err := os.Stat("cov.out")
+				Expect(err).To(BeNil())
+			})
+		})
+
+		It("reads the coverage profile from the output directory", func() {
+			withFakeGo("mkdir -p out && echo header > out/cov.out; echo foo:1.2,1.3 0 >> out/cov.out", func() {
+				writeFile("foo", "")
+				expectCommand(
+					func() int {
+						return runGoTestAndCheckCoverage([]string{"-outputdir=out", "-coverprofile=cov.out", "./..."})
+					},
+					[]interface{}{3, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:1.2,1.3\n"},
+				)
+			})
+		})
+
 		It("can run ginkgo", func() {
 			withFakeExecutable("ginkgo", "touch coverage.out\necho ginkgo \"$@\"", func() {
 				writeFile("foo", "")
@@ -416,46 +830,728 @@ var _ = Describe("go-testcov", func() {
 			})
 		})
 
-		Context("untested block", func() {
-			It("passes when configured to ignore untested block", func() {
-				withFakeGo(
-					// example taken from Readme.md + 1 line
-					"echo header > coverage.out; echo foo:2.13,3.13 0 0 >> coverage.out; echo foo:3.13,4.4 0 0 >> coverage.out; echo foo:6.3,6.18 0 0 >> coverage.out",
-					func() {
-						withFakeGoPath(func(goPath string) {
-							// example taken from Readme.md + 1 line
-							writeFile(joinPath(goPath, "src", "foo"), "// untested block\nfunc main() {\n  if foo(1) {\n      fmt.Print(\"Hi\")\n  }\n  fmt.Print(\"Ho\")\n}")
-							expectCommand(
-								runGoTestWithCoverage,
-								[]interface{}{0, "", ""},
-							)
-						})
+		It("does not pass cover flags to ginkgo twice", func() {
+			withFakeExecutable("ginkgo", "touch cov.out\necho ginkgo \"$@\"", func() {
+				expectCommand(
+					func() int {
+						return runGoTestAndCheckCoverage([]string{"ginkgo", "-cover", "-coverprofile=cov.out", "./..."})
 					},
+					[]interface{}{0, "ginkgo -cover -coverprofile=cov.out ./...\n", ""},
 				)
 			})
+		})
 
-			It("fails when missing coverage is after configured untested block ends", func() {
-				withFakeGo(
-					// example taken from Readme.md + 1 line
-					"echo header > coverage.out; echo foo:2.13,3.13 0 0 >> coverage.out; echo foo:3.13,4.4 0 0 >> coverage.out; echo foo:8.3,8.18 0 0 >> coverage.out",
-					func() {
-						withFakeGoPath(func(goPath string) {
-							// example taken from Readme.md + 1 line
-							writeFile(joinPath(goPath, "src", "foo"), "// untested block\nfunc main() {\n  if foo(1) {\n      fmt.Print(\"Hi\")\n  }\n  fmt.Print(\"Ho\")\n}\nuntested-here")
-							expectCommand(
-								runGoTestWithCoverage,
-								[]interface{}{
-									1,
-									"",
-									"foo new untested sections introduced (1 current vs 0 configured)\nfoo:8.3,8.18\n",
-								},
-							)
-						})
+		It("can run gotestsum", func() {
+			withFakeExecutable("gotestsum", "touch coverage.out\necho gotestsum \"$@\"", func() {
+				expectCommand(
+					func() int {
+						return runGoTestAndCheckCoverage([]string{"gotestsum", "--junitfile", "junit.xml", "--", "-outputdir=.", "./..."})
 					},
+					[]interface{}{0, "gotestsum --junitfile junit.xml -- -outputdir=. ./... -coverprofile coverage.out\n", ""},
 				)
 			})
+		})
 
-			It("warns when untested block is misconfigured", func() {
+		It("can run a custom command", func() {
+			withFakeExecutable("richgo", "touch coverage.out\necho richgo \"$@\"", func() {
+				expectCommand(
+					func() int {
+						return runGoTestAndCheckCoverage([]string{"--runner-cmd=richgo test {coverprofile}", "./..."})
+					},
+					[]interface{}{0, "richgo test -coverprofile coverage.out ./...\n", ""},
+				)
+			})
+		})
+
+		It("fails on custom command without placeholder", func() {
+			inTempDir(func() {
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--runner-cmd=richgo test", "./..."}) },
+					[]interface{}{4, "", "go-testcov: --runner-cmd needs {coverprofile} where the coverage flags go\n"},
+				)
+			})
+		})
+
+		Context("diff", func() {
+			withChangedLines := func(diff string, fn func()) {
+				withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out; echo foo:3.2,3.3 0 >> coverage.out", func() {
+					withFakeExecutable("git", "echo \"$@\" > git-args; cat diff", func() {
+						writeFile("diff", diff)
+						fn()
+					})
+				})
+			}
+
+			It("fails when untested sections are in changed lines", func() {
+				withChangedLines("--- a/foo\n+++ b/foo\n@@ -2,0 +3 @@\n+new\n", func() {
+					writeFile("foo", "old\n\nnew\n")
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--diff=main", "./..."}) },
+						[]interface{}{3, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:3.2,3.3\n"},
+					)
+					Expect(readFile("git-args")).To(Equal("diff --relative --unified=0 --no-color --no-ext-diff main\n"))
+				})
+			})
+
+			It("passes when untested sections are not changed", func() {
+				withChangedLines("--- a/foo\n+++ b/foo\n@@ -2,0 +2 @@\n+new\n", func() {
+					writeFile("foo", "old\nnew\nold\n")
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--diff", "./..."}) },
+						[]interface{}{0, "", ""},
+					)
+					Expect(readFile("git-args")).To(Equal("diff --relative --unified=0 --no-color --no-ext-diff HEAD\n"))
+				})
+			})
+
+			It("ignores configured untested sections", func() {
+				withChangedLines("--- a/foo\n+++ b/foo\n@@ -2,0 +3 @@\n+new\n", func() {
+					writeFile("foo", "// untested sections: 5\n\nnew\n")
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--diff=main", "./..."}) },
+						[]interface{}{3, "", "foo new untested sections introduced (1 current vs 0 configured)\nfoo:3.2,3.3\n"},
+					)
+				})
+			})
+
+			It("passes for ignored files", func() {
+				withChangedLines("--- a/foo\n+++ b/foo\n@@ -2,0 +3 @@\n+new\n", func() {
+					writeFile("foo", "// untested sections: ignore\n\nnew\n")
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--diff=main", "./..."}) },
+						[]interface{}{0, "", ""},
+					)
+				})
+			})
+
+			It("fails with a config error before running tests when the ref is unknown", func() {
+				withFakeGo("echo ran tests", func() {
+					withFakeExecutable("git", "echo \"$@\" > git-args; echo fatal: Needed a single revision >&2; exit 128", func() {
+						expectCommand(
+							func() int { return runGoTestAndCheckCoverage([]string{"--diff=nope", "./..."}) },
+							[]interface{}{4, "", "go-testcov: --diff=nope is not a commit: fatal: Needed a single revision\n"},
+						)
+						Expect(readFile("git-args")).To(Equal("rev-parse --verify nope^{commit}\n"))
+					})
+				})
+			})
+
+			It("fails with a config error when the diff cannot be made", func() {
+				withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out", func() {
+					withFakeExecutable("git", "if [ \"$1\" = diff ]; then echo fatal: bad object >&2; exit 128; fi", func() {
+						writeFile("foo", "")
+						expectCommand(
+							func() int { return runGoTestAndCheckCoverage([]string{"--diff=main", "./..."}) },
+							[]interface{}{4, "", "go-testcov: --diff=main could not be compared: fatal: bad object\n"},
+						)
+					})
+				})
+			})
+		})
+
+		Context("report", func() {
+			withReportableCoverage := func(fn func()) {
+				withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 1 >> coverage.out; echo foo:2.2,2.3 0 >> coverage.out", func() {
+					writeFile("foo", "bar // untested section\nbaz\n")
+					fn()
+				})
+			}
+			expectedJSON := `{
+  "files": [
+    {
+      "displayPath": "foo",
+      "readPath": "foo",
+      "status": "fail",
+      "configuredUntested": 0,
+      "percent": false,
+      "unit": "sections",
+      "configuredIn": "",
+      "configuredAtLine": 0,
+      "actualUntested": 1,
+      "actualUntestedPercent": 50,
+      "percentUntested": 1,
+      "percentTotal": 2,
+      "percentOf": "statements",
+      "untested": [
+        {
+          "endChar": 3,
+          "endLine": 2,
+          "location": "2.2,2.3",
+          "startChar": 2,
+          "startLine": 2
+        }
+      ],
+      "ignores": [
+        {
+          "line": 1,
+          "kind": "section",
+          "reason": "",
+          "expired": false,
+          "suppressed": 0,
+          "stale": true
+        }
+      ],
+      "warnings": [
+        {
+          "line": 1,
+          "message": "has ` + "`// untested section`" + ` but is tested",
+          "rule": "tested-inline-ignore"
+        }
+      ]
+    }
+  ],
+  "packages": []
+}
+`
+
+			It("prints json instead of text", func() {
+				withReportableCoverage(func() {
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--format=json", "./..."}) },
+						[]interface{}{3, expectedJSON, ""},
+					)
+				})
+			})
+
+			It("writes json to a file and keeps text output", func() {
+				withReportableCoverage(func() {
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--report=report.json", "./..."}) },
+						[]interface{}{3, "", "go-testcov (warn): foo:1 has `// untested section` but is tested\nfoo new untested sections introduced (1 current vs 0 configured)\nfoo:2.2,2.3\n"},
+					)
+					Expect(readFile("report.json")).To(Equal(expectedJSON))
+				})
+			})
+
+			It("fails with a config error when the report cannot be written", func() {
+				withReportableCoverage(func() {
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--report=missing/report.json", "./..."}) },
+						[]interface{}{
+							4,
+							"",
+							"go-testcov (warn): foo:1 has `// untested section` but is tested\nfoo new untested sections introduced (1 current vs 0 configured)\nfoo:2.2,2.3\n" +
+								"go-testcov: --report could not be written: open missing/report.json: no such file or directory\n",
+						},
+					)
+				})
+			})
+
+			It("prints github annotations", func() {
+				withReportableCoverage(func() {
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--format=github", "./..."}) },
+						[]interface{}{
+							3,
+							"::warning file=foo,line=1,title=go-testcov::has `// untested section` but is tested\n" +
+								"::error file=foo,line=2,endLine=2,col=2,endColumn=3,title=go-testcov::new untested section introduced (1 current vs 0 configured)\n",
+							"",
+						},
+					)
+				})
+			})
+
+			It("points annotations to files relative to the repository root", func() {
+				withReportableCoverage(func() {
+					module := filepath.Base(mustGetwd())
+					withEnv("GITHUB_WORKSPACE", filepath.Dir(mustGetwd()), func() {
+						expectCommand(
+							func() int { return runGoTestAndCheckCoverage([]string{"--format=github", "./..."}) },
+							[]interface{}{
+								3,
+								"::warning file=" + module + "/foo,line=1,title=go-testcov::has `// untested section` but is tested\n" +
+									"::error file=" + module + "/foo,line=2,endLine=2,col=2,endColumn=3,title=go-testcov::new untested section introduced (1 current vs 0 configured)\n",
+								"",
+							},
+						)
+						expectCommand(
+							func() int {
+								return runGoTestAndCheckCoverage([]string{"--format=sarif", "--report=report.sarif", "./..."})
+							},
+							[]interface{}{3, "", "go-testcov (warn): foo:1 has `// untested section` but is tested\nfoo new untested sections introduced (1 current vs 0 configured)\nfoo:2.2,2.3\n"},
+						)
+						Expect(readFile("report.sarif")).To(ContainSubstring(`"uri": "` + module + `/foo"`))
+					})
+				})
+			})
+
+			It("prints github annotation for decrementing configured untested", func() {
+				withFakeGo("echo header > coverage.out; echo foo:2.2,2.3 0 >> coverage.out", func() {
+					writeFile("foo", "// untested sections: 2\nbaz\n")
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--format=github", "./..."}) },
+						[]interface{}{
+							0,
+							"::notice file=foo,line=1,title=go-testcov::less untested sections (1 current vs 2 configured), decrement configured untested?\n",
+							"",
+						},
+					)
+				})
+			})
+
+			It("writes sarif to a file", func() {
+				withReportableCoverage(func() {
+					expectCommand(
+						func() int {
+							return runGoTestAndCheckCoverage([]string{"--format=sarif", "--report=report.sarif", "./..."})
+						},
+						[]interface{}{3, "", "go-testcov (warn): foo:1 has `// untested section` but is tested\nfoo new untested sections introduced (1 current vs 0 configured)\nfoo:2.2,2.3\n"},
+					)
+					Expect(readFile("report.sarif")).To(ContainSubstring(`"ruleId": "untested-section"`))
+				})
+			})
+
+			It("fails on unknown format", func() {
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--format=xml", "./..."}) },
+					[]interface{}{4, "", "go-testcov: unknown --format \"xml\", use one of text, json, github, sarif\n"},
+				)
+			})
+		})
+
+		It("merges binary coverage after running tests", func() {
+			script := `if [ "$1" = tool ]; then for arg in "$@"; do case $arg in -o=*) out=${arg#-o=};; esac; done; printf 'mode: set\nfoo:2.2,2.3 1 1\n' > $out; else printf 'mode: set\nfoo:1.2,1.3 1 1\nfoo:2.2,2.3 1 0\n' > coverage.out; fi`
+			withFakeGo(script, func() {
+				writeFile("foo", "bar\nbaz\n")
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--covdir=e2e", "./..."}) },
+					[]interface{}{0, "", ""},
+				)
+			})
+		})
+
+		It("fails when binary coverage cannot be merged", func() {
+			script := `if [ "$1" = tool ]; then exit 1; else touch coverage.out; fi`
+			withFakeGo(script, func() {
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--covdir=e2e", "./..."}) },
+					[]interface{}{5, "", "go-testcov: go tool covdata failed with exit code 1\n"},
+				)
+			})
+		})
+
+		It("fails when asked to check a profile", func() {
+			inTempDir(func() {
+				expectCommand(
+					func() int { return runGoTestAndCheckCoverage([]string{"--profile=coverage.out", "./..."}) },
+					[]interface{}{4, "", "go-testcov: --profile only works with `go-testcov check`, which does not run tests\n"},
+				)
+			})
+		})
+
+		Context("config", func() {
+			It("uses thresholds, generated files, format and flags from the config", func() {
+				withFakeGo("echo header > coverage.out; echo foo.go:1.2,1.3 0 >> coverage.out; echo gen/bar.go:1.2,1.3 0 >> coverage.out; echo baz.go:1.2,1.3 0 >> coverage.out; echo go \"$@\" >&2", func() {
+					writeFile("foo.go", "")
+					writeFile("baz.go", "")
+					os.Mkdir("gen", 0700)
+					writeFile("gen/bar.go", "")
+					writeFile(".go-testcov.yml", "format: github\nflags: -race\ngenerated:\n  - gen/*\nuntested:\n  foo.go: 2\n  '*.go': 1\n")
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{
+							0,
+							"::notice file=.go-testcov.yml,line=6,title=go-testcov::less untested sections (1 current vs 2 configured), decrement configured untested?\n",
+							"go test -race hello world -coverprofile coverage.out\n",
+						},
+					)
+				})
+			})
+
+			It("lets options override the config", func() {
+				withFakeGo("echo header > coverage.out; echo foo.go:1.2,1.3 0 >> coverage.out", func() {
+					writeFile("foo.go", "")
+					writeFile(".go-testcov.yml", "format: github\n")
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--format=text", "./..."}) },
+						[]interface{}{3, "", "foo.go new untested sections introduced (1 current vs 0 configured)\nfoo.go:1.2,1.3\n"},
+					)
+				})
+			})
+
+			It("passes default flags to ginkgo after the binary", func() {
+				withFakeExecutable("ginkgo", "touch coverage.out\necho ginkgo \"$@\"", func() {
+					writeFile(".go-testcov.yml", "flags:\n  - -race\n")
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"ginkgo", "./..."}) },
+						[]interface{}{0, "ginkgo -race -cover -coverprofile coverage.out ./...\n", ""},
+					)
+				})
+			})
+
+			It("fails on invalid config", func() {
+				inTempDir(func() {
+					writeFile(".go-testcov.yml", "nope: 1\n")
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{4, "", "go-testcov: " + joinPath(mustGetwd(), ".go-testcov.yml") + ":1: unknown key \"nope\"\n"},
+					)
+				})
+			})
+		})
+
+		Context("baseline", func() {
+			withBaselineCoverage := func(untested int, fn func()) {
+				script := "echo header > coverage.out; echo foo:1.2,1.3 1 >> coverage.out"
+				for i := 0; i < untested; i++ {
+					script += fmt.Sprintf("; echo foo:%v.2,%v.3 0 >> coverage.out", i+2, i+2)
+				}
+				withFakeGo(script, func() {
+					writeFile("foo", "\n\n\n\n")
+					fn()
+				})
+			}
+
+			It("writes the baseline instead of failing", func() {
+				withBaselineCoverage(2, func() {
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--update-baseline", "./..."}) },
+						[]interface{}{0, "", "go-testcov: wrote 1 files with untested sections to .testcov-baseline.json\n"},
+					)
+					Expect(readFile(baselineFileName)).To(Equal("{\n  \"foo\": 2\n}\n"))
+				})
+			})
+
+			It("passes when untested matches the baseline", func() {
+				withBaselineCoverage(2, func() {
+					writeFile(baselineFileName, "{\n  \"foo\": 2\n}\n")
+					expectCommand(runGoTestWithCoverage, []interface{}{0, "", ""})
+				})
+			})
+
+			It("fails when untested grows", func() {
+				withBaselineCoverage(2, func() {
+					writeFile(baselineFileName, "{\n  \"foo\": 1\n}\n")
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{3, "", "foo new untested sections introduced (2 current vs 1 configured)\nfoo:2.2,2.3\nfoo:3.2,3.3\n"},
+					)
+				})
+			})
+
+			It("asks to decrement when untested shrinks", func() {
+				withBaselineCoverage(1, func() {
+					writeFile(baselineFileName, "{\n  \"foo\": 2\n}\n")
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{0, "", "foo has less untested sections (1 current vs 2 configured), decrement configured untested?\nconfigured on: .testcov-baseline.json:2"},
+					)
+				})
+			})
+
+			It("fails when the baseline cannot be written", func() {
+				withBaselineCoverage(2, func() {
+					noError(os.Mkdir(baselineFileName, 0700))
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--update-baseline", "./..."}) },
+						[]interface{}{4, "", "go-testcov: baseline could not be written: open .testcov-baseline.json: is a directory\n"},
+					)
+				})
+			})
+
+			It("fails on invalid baseline", func() {
+				inTempDir(func() {
+					writeFile(baselineFileName, "{")
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{4, "", "go-testcov: .testcov-baseline.json: unexpected end of JSON input\n"},
+					)
+				})
+			})
+		})
+
+		Context("fix", func() {
+			runFix := func() int { return runGoTestAndCheckCoverage([]string{"--fix", "./..."}) }
+
+			It("decrements configured untested", func() {
+				withFakeGo("echo header > coverage.out; echo foo:2.2,2.3 0 >> coverage.out", func() {
+					writeFile("foo", "package foo // untested sections: 3 because legacy\nbar\n")
+					expectCommand(
+						runFix,
+						[]interface{}{0, "", "go-testcov (fix): foo:1 decremented `// untested sections` to 1\n"},
+					)
+					Expect(readFile("foo")).To(Equal("package foo // untested sections: 1 because legacy\nbar\n"))
+				})
+			})
+
+			It("removes configured untested when everything is tested", func() {
+				withFakeGo("echo header > coverage.out; echo foo:2.2,2.3 1 >> coverage.out", func() {
+					writeFile("foo", "// untested sections: 3\nbar\n")
+					expectCommand(
+						runFix,
+						[]interface{}{0, "", "go-testcov (fix): foo:1 removed `// untested sections`\n"},
+					)
+					Expect(readFile("foo")).To(Equal("bar\n"))
+				})
+			})
+
+			It("removes inline ignores on tested code", func() {
+				withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 1 >> coverage.out; echo foo:3.2,3.3 1 >> coverage.out; echo foo:4.2,4.3 1 >> coverage.out", func() {
+					writeFile("foo", "url := \"http://x\" // untested section: why\n\t// untested section\nbar\nbaz // untested section random\n")
+					expectCommand(
+						runFix,
+						[]interface{}{
+							0,
+							"",
+							"go-testcov (fix): foo:1 removed tested `// untested section`\ngo-testcov (fix): foo:2 removed tested `// untested section`\n",
+						},
+					)
+					Expect(readFile("foo")).To(Equal("url := \"http://x\"\nbar\nbaz // untested section random\n"))
+				})
+			})
+
+			It("does not remove expired markers", func() {
+				withFakeGo("echo header > coverage.out; echo foo:3.1,3.4 0 >> coverage.out", func() {
+					content := "// untested sections: 1 until=2020-01-01\n// untested block until 2020-01-01\nbar\n"
+					writeFile("foo", content)
+					expectCommand(
+						runFix,
+						[]interface{}{3, "", "" +
+							"go-testcov (warn): foo:1 has an ignore marker that expired on 2020-01-01\n" +
+							"go-testcov (warn): foo:2 has an ignore marker that expired on 2020-01-01\n" +
+							"foo new untested sections introduced (1 current vs 0 configured)\nfoo:3.1,3.4\n"},
+					)
+					Expect(readFile("foo")).To(Equal(content))
+				})
+			})
+
+			It("fails when the file cannot be written", func() {
+				inTempDir(func() {
+					noError(os.Mkdir("foo", 0700))
+					var err error
+					captureStderr(func() {
+						_, err = fixFile(FileReport{DisplayPath: "foo", ReadPath: "foo", Warnings: []Warning{{Line: 1, Rule: ruleTestedIgnore}}}, "bar // untested section\n")
+					})
+					Expect(err).To(MatchError("foo: covered file could not be fixed: open foo: is a directory"))
+				})
+			})
+
+			It("does not change files that are fine", func() {
+				withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 0 >> coverage.out", func() {
+					writeFile("foo", "// untested sections: 1\n")
+					expectCommand(runFix, []interface{}{0, "", ""})
+					Expect(readFile("foo")).To(Equal("// untested sections: 1\n"))
+				})
+			})
+		})
+
+		Context("package", func() {
+			withPackageCoverage := func(fn func()) {
+				withFakeGo("echo header > coverage.out; echo pkg/a.go:2.2,2.3 0 >> coverage.out; echo pkg/b.go:2.2,2.3 0 >> coverage.out", func() {
+					os.Mkdir("pkg", 0700)
+					writeFile("pkg/a.go", "package pkg\nfoo\n")
+					writeFile("pkg/b.go", "package pkg\nbar\n")
+					fn()
+				})
+			}
+
+			It("passes when the package has as many untested sections as configured in doc.go", func() {
+				withPackageCoverage(func() {
+					writeFile("pkg/doc.go", "// untested sections: 2\npackage pkg\n")
+					expectCommand(runGoTestWithCoverage, []interface{}{0, "", ""})
+				})
+			})
+
+			It("fails when the package has more untested sections than configured in doc.go", func() {
+				withPackageCoverage(func() {
+					writeFile("pkg/doc.go", "// untested sections: 1\npackage pkg\n")
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{3, "", "pkg package new untested sections introduced (2 current vs 1 configured)\npkg/a.go:2.2,2.3\npkg/b.go:2.2,2.3\n"},
+					)
+				})
+			})
+
+			It("sums up a package that has a sub-package with its own doc.go", func() {
+				withFakeGo("echo header > coverage.out; echo a/b.go:2.2,2.3 0 >> coverage.out; echo a/b/d.go:2.2,2.3 0 >> coverage.out; echo a/c.go:2.2,2.3 0 >> coverage.out", func() {
+					noError(os.MkdirAll("a/b", 0700))
+					writeFile("a/doc.go", "// untested sections: 1\npackage a\n")
+					writeFile("a/b.go", "package a\nfoo\n")
+					writeFile("a/c.go", "package a\nbar\n")
+					writeFile("a/b/doc.go", "// untested sections: 5\npackage b\n")
+					writeFile("a/b/d.go", "package b\nbaz\n")
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{3, "", "" +
+							"a package new untested sections introduced (2 current vs 1 configured)\na/b.go:2.2,2.3\na/c.go:2.2,2.3\n" +
+							"a/b package has less untested sections (1 current vs 5 configured), decrement configured untested?\nconfigured on: a/b/doc.go:1"},
+					)
+				})
+			})
+
+			It("warns when the package has less untested sections than configured in doc.go", func() {
+				withPackageCoverage(func() {
+					writeFile("pkg/doc.go", "// untested sections: 3\npackage pkg\n")
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--format=github", "./..."}) },
+						[]interface{}{0, "::notice file=pkg/doc.go,line=1,title=go-testcov::less untested sections in package pkg (2 current vs 3 configured), decrement configured untested?\n", ""},
+					)
+				})
+			})
+
+			It("fails on an invalid comment in doc.go", func() {
+				withPackageCoverage(func() {
+					writeFile("pkg/doc.go", "// untested sections: some\npackage pkg\n")
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{4, "", "go-testcov: pkg/doc.go:1: expected a count, percentage or ignore but got \"some\"\n"},
+					)
+				})
+			})
+
+			It("fails when doc.go cannot be read", func() {
+				withPackageCoverage(func() {
+					noError(os.Mkdir("pkg/doc.go", 0700))
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{4, "", "go-testcov: read pkg/doc.go: is a directory\n"},
+					)
+				})
+			})
+
+			It("fails on an invalid comment in a file", func() {
+				withPackageCoverage(func() {
+					writeFile("pkg/a.go", "package pkg // untested sections: some\nfoo\n")
+					expectCommand(
+						runGoTestWithCoverage,
+						[]interface{}{4, "", "go-testcov: pkg/a.go:1: expected a count, percentage or ignore but got \"some\"\n"},
+					)
+				})
+			})
+
+			It("lets the file comment win over doc.go", func() {
+				withPackageCoverage(func() {
+					writeFile("pkg/doc.go", "// untested sections: 1\npackage pkg\n")
+					writeFile("pkg/b.go", "package pkg // untested sections: 1\nbar\n")
+					expectCommand(runGoTestWithCoverage, []interface{}{0, "", ""})
+				})
+			})
+		})
+
+		Context("min total", func() {
+			withTotalCoverage := func(fn func()) {
+				withFakeGo("echo header > coverage.out; echo foo:1.2,1.3 1 >> coverage.out; echo foo:2.2,2.3 1 >> coverage.out; echo foo:3.2,3.3 0 >> coverage.out", func() {
+					writeFile("foo", "// untested sections: 1\nbar\nbaz\n")
+					fn()
+				})
+			}
+
+			It("passes when total coverage is above the minimum", func() {
+				withTotalCoverage(func() {
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--min-total=66%", "./..."}) },
+						[]interface{}{0, "", ""},
+					)
+				})
+			})
+
+			It("fails when total coverage is below the minimum", func() {
+				withTotalCoverage(func() {
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--min-total=67", "./..."}) },
+						[]interface{}{3, "", "total coverage 66% is below the minimum of 67%\n"},
+					)
+				})
+			})
+
+			It("prints a github annotation when total coverage is below the minimum", func() {
+				withTotalCoverage(func() {
+					expectCommand(
+						func() int { return runGoTestAndCheckCoverage([]string{"--min-total=80%", "--format=github", "./..."}) },
+						[]interface{}{3, "::error title=go-testcov::total coverage 66%25 is below the minimum of 80%25\n", ""},
+					)
+				})
+			})
+		})
+
+		Context("unit", func() {
+			withStatementCoverage := func(header string, fn func()) {
+				withFakeGo("echo header > coverage.out; echo foo:2.2,2.3 1 1 >> coverage.out; echo foo:3.2,5.3 3 0 >> coverage.out", func() {
+					writeFile("foo", header+"\nbar\nbaz\n\n\n")
+					fn()
+				})
+			}
+			runWithStatements := func(args ...string) func() int {
+				return func() int { return runGoTestAndCheckCoverage(append([]string{"--unit=statements"}, args...)) }
+			}
+
+			It("counts untested statements", func() {
+				withStatementCoverage("", func() {
+					expectCommand(
+						runWithStatements("./..."),
+						[]interface{}{3, "", "foo new untested statements introduced (3 current vs 0 configured)\nfoo:3.2,5.3\n"},
+					)
+				})
+			})
+
+			It("compares statements to the configured untested", func() {
+				withStatementCoverage("// untested sections: 4", func() {
+					expectCommand(
+						runWithStatements("./..."),
+						[]interface{}{0, "", "foo has less untested statements (3 current vs 4 configured), decrement configured untested?\nconfigured on: foo:1"},
+					)
+				})
+			})
+
+			It("uses statements for percentages", func() {
+				withStatementCoverage("// untested sections: 70%", func() {
+					expectCommand(
+						runWithStatements("./..."),
+						[]interface{}{3, "", "foo new untested statements introduced (75% current vs 70% configured, 3 of 4 statements untested)\nfoo:3.2,5.3\n"},
+					)
+				})
+			})
+
+			It("uses statements for the total like go test -cover", func() {
+				withStatementCoverage("// untested sections: ignore", func() {
+					expectCommand(
+						runWithStatements("--min-total=30%", "./..."),
+						[]interface{}{3, "", "total coverage 25% is below the minimum of 30%\n"},
+					)
+				})
+			})
+		})
+
+		Context("untested block", func() {
+			It("passes when configured to ignore untested block", func() {
+				withFakeGo(
+					// example taken from Readme.md + 1 line
+					"echo header > coverage.out; echo foo:2.13,3.13 0 0 >> coverage.out; echo foo:3.13,4.4 0 0 >> coverage.out; echo foo:6.3,6.18 0 0 >> coverage.out",
+					func() {
+						withFakeGoPath(func(goPath string) {
+							// example taken from Readme.md + 1 line
+							writeFile(joinPath(goPath, "src", "foo"), "// untested block\nfunc main() {\n  if foo(1) {\n      fmt.Print(\"Hi\")\n  }\n  fmt.Print(\"Ho\")\n}")
+							expectCommand(
+								runGoTestWithCoverage,
+								[]interface{}{0, "", ""},
+							)
+						})
+					},
+				)
+			})
+
+			It("fails when missing coverage is after configured untested block ends", func() {
+				withFakeGo(
+					// example taken from Readme.md + 1 line
+					"echo header > coverage.out; echo foo:2.13,3.13 0 0 >> coverage.out; echo foo:3.13,4.4 0 0 >> coverage.out; echo foo:8.3,8.18 0 0 >> coverage.out",
+					func() {
+						withFakeGoPath(func(goPath string) {
+							// example taken from Readme.md + 1 line
+							writeFile(joinPath(goPath, "src", "foo"), "// untested block\nfunc main() {\n  if foo(1) {\n      fmt.Print(\"Hi\")\n  }\n  fmt.Print(\"Ho\")\n}\nuntested-here")
+							expectCommand(
+								runGoTestWithCoverage,
+								[]interface{}{
+									3,
+									"",
+									"foo new untested sections introduced (1 current vs 0 configured)\nfoo:8.3,8.18\n",
+								},
+							)
+						})
+					},
+				)
+			})
+
+			It("warns when untested block is misconfigured", func() {
 				withFakeGo(
 					// example taken from Readme.md + 1 line
 					"echo header > coverage.out; echo foo:2.13,3.13 0 0 >> coverage.out; echo foo:3.13,4.4 0 0 >> coverage.out; echo foo:8.3,8.18 0 0 >> coverage.out",
@@ -466,7 +1562,7 @@ var _ = Describe("go-testcov", func() {
 							expectCommand(
 								runGoTestWithCoverage,
 								[]interface{}{
-									1,
+									3,
 									"",
 									"go-testcov: unable to find the end of the `// untested block` started between 1 and 2, a line starting with \t\t\t\t\t}foo new untested sections introduced (3 current vs 0 configured)\nfoo:2.13,3.13\nfoo:3.13,4.4\nfoo:8.3,8.18\n",
 								},
@@ -478,100 +1574,133 @@ var _ = Describe("go-testcov", func() {
 		})
 	})
 
-	Describe("warnCoveredInlineIgnore", func() {
+	Describe("findCoveredInlineIgnores", func() {
 		It("warns when inline comment is on covered code", func() {
-			stderr := captureStderr(func() {
-				warnCoveredInlineIgnore(
-					"foo.go",
-					[]Section{{"foo.go", 1, 2, 1, 3, 100002, 1}},
-					[]string{"foo // untested section"},
-				)
-			})
-			Expect(stderr).To(Equal("go-testcov (warn): foo.go:1 has `// untested section` but is tested\n"))
+			warnings := findCoveredInlineIgnores(
+				[]Section{{"foo.go", 1, 2, 1, 3, 100002, 1, 1}},
+				[]string{"foo // untested section"},
+			)
+			Expect(warnings).To(Equal([]Warning{{1, "has `// untested section` but is tested", ruleTestedIgnore}}))
 		})
 
 		It("warns when inline comment is above covered code", func() {
-			stderr := captureStderr(func() {
-				warnCoveredInlineIgnore(
-					"foo.go",
-					[]Section{{"foo.go", 2, 2, 2, 3, 200002, 1}},
-					[]string{"// untested section", "foo"},
-				)
-			})
-			Expect(stderr).To(Equal("go-testcov (warn): foo.go:1 has `// untested section` but the code below is tested\n"))
+			warnings := findCoveredInlineIgnores(
+				[]Section{{"foo.go", 2, 2, 2, 3, 200002, 1, 1}},
+				[]string{"// untested section", "foo"},
+			)
+			Expect(warnings).To(Equal([]Warning{{1, "has `// untested section` but the code below is tested", ruleTestedIgnore}}))
 		})
 
 		It("does not warn when inline comment has random suffix", func() {
-			stderr := captureStderr(func() {
-				warnCoveredInlineIgnore(
-					"foo.go",
-					[]Section{{"foo.go", 1, 2, 1, 3, 100002, 1}},
-					[]string{"foo // untested section random"},
-				)
-			})
-			Expect(stderr).To(Equal(""))
+			warnings := findCoveredInlineIgnores(
+				[]Section{{"foo.go", 1, 2, 1, 3, 100002, 1, 1}},
+				[]string{"foo // untested section random"},
+			)
+			Expect(warnings).To(Equal([]Warning{}))
 		})
 
 		It("does not warn when above-line comment has random suffix", func() {
-			stderr := captureStderr(func() {
-				warnCoveredInlineIgnore(
-					"foo.go",
-					[]Section{{"foo.go", 2, 2, 2, 3, 200002, 1}},
-					[]string{"// untested section random", "foo"},
-				)
-			})
-			Expect(stderr).To(Equal(""))
+			warnings := findCoveredInlineIgnores(
+				[]Section{{"foo.go", 2, 2, 2, 3, 200002, 1, 1}},
+				[]string{"// untested section random", "foo"},
+			)
+			Expect(warnings).To(Equal([]Warning{}))
 		})
 
 		It("does not warn when inline comment is on uncovered code", func() {
-			stderr := captureStderr(func() {
-				warnCoveredInlineIgnore(
-					"foo.go",
-					[]Section{},
-					[]string{"foo // untested section"},
-				)
-			})
-			Expect(stderr).To(Equal(""))
+			warnings := findCoveredInlineIgnores(
+				[]Section{},
+				[]string{"foo // untested section"},
+			)
+			Expect(warnings).To(Equal([]Warning{}))
 		})
 
 		It("does not warn when one of multiple sections on the line is uncovered", func() {
-			stderr := captureStderr(func() {
-				warnCoveredInlineIgnore(
-					"foo.go",
-					[]Section{
-						{"foo.go", 1, 2, 1, 3, 100002, 1},
-						{"foo.go", 1, 4, 1, 6, 100004, 0},
-					},
-					[]string{"foo || bar // untested section"},
-				)
-			})
-			Expect(stderr).To(Equal(""))
+			warnings := findCoveredInlineIgnores(
+				[]Section{
+					{"foo.go", 1, 2, 1, 3, 100002, 1, 1},
+					{"foo.go", 1, 4, 1, 6, 100004, 1, 0},
+				},
+				[]string{"foo || bar // untested section"},
+			)
+			Expect(warnings).To(Equal([]Warning{}))
 		})
 
 		It("keeps random suffix inline comments as ignores", func() {
 			sections := removeSectionsMarkedWithInlineComment(
-				[]Section{{"foo.go", 1, 2, 1, 3, 100002, 0}},
+				[]Section{{"foo.go", 1, 2, 1, 3, 100002, 1, 0}},
 				[]string{"foo // untested section random"},
 			)
 			Expect(sections).To(Equal([]Section{}))
 		})
 	})
 
+	Describe("hasGeneratedHeader", func() {
+		It("finds the header before the package clause", func() {
+			Expect(hasGeneratedHeader("// Code generated by mockgen. DO NOT EDIT.\r\n// Source: foo.go\r\npackage foo\r\n")).To(BeTrue())
+		})
+
+		It("ignores the header after the package clause", func() {
+			Expect(hasGeneratedHeader("package foo\n// Code generated by mockgen. DO NOT EDIT.\n")).To(BeFalse())
+		})
+
+		It("ignores similar comments", func() {
+			Expect(hasGeneratedHeader("// Code generated by hand, please EDIT.\n")).To(BeFalse())
+		})
+	})
+
+	Describe("percent", func() {
+		It("rounds", func() {
+			Expect(percent(2, 3)).To(Equal(67))
+		})
+
+		It("is 0 when there is nothing", func() {
+			Expect(percent(0, 0)).To(Equal(0))
+		})
+	})
+
 	Describe("getSections", func() {
 		It("shows nothing for empty", func() {
 			withTempFile("", func(file *os.File) {
-				Expect(getSections(file.Name())).To(Equal([]Section{}))
+				mode, sections, err := getSections(file.Name())
+				Expect(err).To(BeNil())
+				Expect(mode).To(Equal(""))
+				Expect(sections).To(Equal([]Section{}))
 			})
 		})
 
 		It("parses compact and full count formats", func() {
-			withTempFile("mode: set\nfoo/pkg.go:1.2,3.4 1 0\nfoo/pkg.go:5.2,5.4 1 10\n", func(file *os.File) {
-				Expect(getSections(file.Name())).To(Equal([]Section{
-					{"foo/pkg.go", 1, 2, 3, 4, 100002, 0},
-					{"foo/pkg.go", 5, 2, 5, 4, 500002, 10},
+			withTempFile("mode: set\nfoo/pkg.go:1.2,3.4 1 0\nfoo/pkg.go:5.2,5.4 10\n", func(file *os.File) {
+				mode, sections, err := getSections(file.Name())
+				Expect(err).To(BeNil())
+				Expect(mode).To(Equal("set"))
+				Expect(sections).To(Equal([]Section{
+					{"foo/pkg.go", 1, 2, 3, 4, 100002, 1, 0},
+					{"foo/pkg.go", 5, 2, 5, 4, 500002, 1, 10},
 				}))
 			})
 		})
+
+		It("fails on malformed lines", func() {
+			withTempFile("mode: set\nfoo/pkg.go:1.2,3.4 1 0\nfoo/pkg.go 1\n", func(file *os.File) {
+				_, _, err := getSections(file.Name())
+				Expect(err).To(MatchError(file.Name() + ":3: expected `file:line.column,line.column statements count` but got \"foo/pkg.go 1\""))
+			})
+		})
+
+		It("fails on numbers that are too big", func() {
+			withTempFile("mode: set\nfoo/pkg.go:1.2,3.4 1 99999999999999999999\n", func(file *os.File) {
+				_, _, err := getSections(file.Name())
+				Expect(err).To(MatchError(ContainSubstring(file.Name() + ":2: strconv.Atoi")))
+			})
+		})
+
+		It("fails on missing files", func() {
+			inTempDir(func() {
+				_, _, err := getSections("nope.out")
+				Expect(err).To(MatchError("open nope.out: no such file or directory"))
+			})
+		})
 	})
 
 	Describe("untestedFromSections", func() {
@@ -581,37 +1710,50 @@ var _ = Describe("go-testcov", func() {
 
 		It("keeps only sections with count 0", func() {
 			input := []Section{
-				{"foo/pkg.go", 1, 2, 3, 4, 100002, 0},
-				{"foo/pkg.go", 5, 2, 5, 4, 500002, 10},
+				{"foo/pkg.go", 1, 2, 3, 4, 100002, 1, 0},
+				{"foo/pkg.go", 5, 2, 5, 4, 500002, 1, 10},
 			}
 			Expect(untestedFromSections(input)).To(Equal([]Section{
-				{"foo/pkg.go", 1, 2, 3, 4, 100002, 0},
+				{"foo/pkg.go", 1, 2, 3, 4, 100002, 1, 0},
 			}))
 		})
 
 		It("keeps multiple untested sections in order", func() {
 			input := []Section{
-				{"foo/pkg.go", 1, 2, 3, 4, 100002, 0},
-				{"foo/pkg.go", 5, 2, 5, 4, 500002, 10},
-				{"foo/pkg.go", 6, 2, 6, 4, 600002, 0},
+				{"foo/pkg.go", 1, 2, 3, 4, 100002, 1, 0},
+				{"foo/pkg.go", 5, 2, 5, 4, 500002, 1, 10},
+				{"foo/pkg.go", 6, 2, 6, 4, 600002, 1, 0},
 			}
 			Expect(untestedFromSections(input)).To(Equal([]Section{
-				{"foo/pkg.go", 1, 2, 3, 4, 100002, 0},
-				{"foo/pkg.go", 6, 2, 6, 4, 600002, 0},
+				{"foo/pkg.go", 1, 2, 3, 4, 100002, 1, 0},
+				{"foo/pkg.go", 6, 2, 6, 4, 600002, 1, 0},
 			}))
 		})
 
 		It("returns empty when all sections are covered", func() {
-			input := []Section{{"foo/pkg.go", 5, 2, 5, 4, 500002, 10}}
+			input := []Section{{"foo/pkg.go", 5, 2, 5, 4, 500002, 1, 10}}
 			Expect(untestedFromSections(input)).To(Equal([]Section{}))
 		})
 	})
 
 	Describe("configuredUntestedForFile", func() {
+		It("fails on invalid dates", func() {
+			_, _, _, err := configuredUntestedForFile("foo", "// untested sections: 5 until=2026-1-1")
+			Expect(err).To(BeNil()) // not a date, so just text
+			_, _, _, err = configuredUntestedForFile("foo", "// untested sections: 5 until=2026-00-01")
+			Expect(err).To(MatchError("foo:1: expected a date like until=2026-12-31 but got \"2026-00-01\""))
+		})
+
+		It("fails on invalid values", func() {
+			_, _, _, err := configuredUntestedForFile("foo", "\n// untested sections: some")
+			Expect(err).To(MatchError("foo:2: expected a count, percentage or ignore but got \"some\""))
+			Expect(errors.As(err, &ConfigError{})).To(BeTrue())
+		})
+
 		It("returns 0,0 when not configured", func() {
 			inTempDir(func() {
-				writeFile(joinPath("foo"), "")
-				count, percent, line := configuredUntestedForFile("foo")
+				count, percent, line, err := configuredUntestedForFile("foo", "")
+				Expect(err).To(BeNil())
 				Expect(count).To(Equal(0))
 				Expect(percent).To(Equal(false))
 				Expect(line).To(Equal(0))
@@ -620,8 +1762,8 @@ var _ = Describe("go-testcov", func() {
 
 		It("returns number of untested and line number of comment when configured", func() {
 			inTempDir(func() {
-				writeFile("foo", "// untested sections: 12")
-				count, percent, line := configuredUntestedForFile("foo")
+				count, percent, line, err := configuredUntestedForFile("foo", "// untested sections: 12")
+				Expect(err).To(BeNil())
 				Expect(count).To(Equal(12))
 				Expect(percent).To(Equal(false))
 				Expect(line).To(Equal(1))
@@ -630,8 +1772,8 @@ var _ = Describe("go-testcov", func() {
 
 		It("returns number of untested and line number of comment when configured with multiple lines", func() {
 			inTempDir(func() {
-				writeFile("foo", "... bork ... \n // untested sections: 12 \n ... bork ...")
-				count, percent, line := configuredUntestedForFile("foo")
+				count, percent, line, err := configuredUntestedForFile("foo", "... bork ... \n // untested sections: 12 \n ... bork ...")
+				Expect(err).To(BeNil())
 				Expect(count).To(Equal(12))
 				Expect(percent).To(Equal(false))
 				Expect(line).To(Equal(2))
@@ -640,8 +1782,8 @@ var _ = Describe("go-testcov", func() {
 
 		It("returns ignored when configured", func() {
 			inTempDir(func() {
-				writeFile("foo", "... bork ... \n // untested sections: ignore \n ... bork ...")
-				count, percent, line := configuredUntestedForFile("foo")
+				count, percent, line, err := configuredUntestedForFile("foo", "... bork ... \n // untested sections: ignore \n ... bork ...")
+				Expect(err).To(BeNil())
 				Expect(count).To(Equal(100))
 				Expect(percent).To(Equal(true))
 				Expect(line).To(Equal(2))
@@ -650,8 +1792,8 @@ var _ = Describe("go-testcov", func() {
 
 		It("returns percent when configured", func() {
 			inTempDir(func() {
-				writeFile("foo", "... bork ... \n // untested sections: 10% \n ... bork ...")
-				count, percent, line := configuredUntestedForFile("foo")
+				count, percent, line, err := configuredUntestedForFile("foo", "... bork ... \n // untested sections: 10% \n ... bork ...")
+				Expect(err).To(BeNil())
 				Expect(count).To(Equal(10))
 				Expect(percent).To(Equal(true))
 				Expect(line).To(Equal(2))
@@ -0,0 +1 @@
+../markers.go
\ No newline at end of file
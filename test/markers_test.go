@@ -0,0 +1,124 @@
+package main
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// parse sections written like in a coverage profile
+func sectionsFromProfile(profile string) (sections []Section) {
+	for _, line := range strings.Split(strings.TrimSpace(profile), "\n") {
+		section, err := NewSection(line)
+		noError(err)
+		sections = append(sections, section)
+	}
+	return
+}
+
+func locations(sections []Section) (locations []string) {
+	locations = []string{}
+	for _, section := range sections {
+		locations = append(locations, section.Location())
+	}
+	return
+}
+
+var _ = Describe("markers", func() {
+	Describe("removeIgnoredSections", func() {
+		expectKept := func(content string, profile string, expected []string) {
+			sections := sectionsFromProfile(profile)
+			kept := removeIgnoredSections("a.go", content, sections, untestedFromSections(sections))
+			ExpectWithOffset(1, locations(kept)).To(Equal(expected))
+		}
+
+		It("scopes markers after an opening brace to the block", func() {
+			expectKept(
+				"package a\n\nfunc a(x int) int {\n\tif x > 1 { // untested section\n\t\treturn 1\n\t} else if x > 0 {\n\t\treturn 2\n\t} else { // untested section\n\t\treturn 3\n\t}\n}\n",
+				"a.go:4.2,4.11 1 0\na.go:5.3,6.1 1 0\na.go:6.9,6.18 1 0\na.go:7.3,8.1 1 0\na.go:9.3,10.1 1 0\n",
+				[]string{"4.2,4.11", "6.9,6.18", "7.3,8.1"},
+			)
+		})
+
+		It("scopes markers to case clauses", func() {
+			expectKept(
+				"package a\n\nfunc b(x int) int {\n\tswitch x {\n\tcase 1: // untested section\n\t\treturn 1\n\t// untested section\n\tcase 2:\n\t\treturn 2\n\tcase 3:\n\t\treturn 3\n\t}\n\treturn 0\n}\n",
+				"a.go:4.2,4.11 1 1\na.go:6.3,6.11 1 0\na.go:9.3,9.11 1 0\na.go:11.3,11.11 1 0\na.go:13.2,13.10 1 1\n",
+				[]string{"11.3,11.11"},
+			)
+		})
+
+		It("scopes markers on simple statements to the section that runs them", func() {
+			expectKept(
+				"package a\n\nfunc c(x int) int {\n\tif x > 0 {\n\t\tpanic(\"x\") // untested section\n\t}\n\t// untested block\n\tif x < 0 {\n\t\treturn 1\n\t}\n\treturn 2\n}\n",
+				"a.go:4.2,4.11 1 1\na.go:5.3,5.13 1 0\na.go:8.2,8.11 1 0\na.go:9.3,10.1 1 0\na.go:11.2,11.10 1 1\n",
+				[]string{},
+			)
+		})
+
+		It("scopes markers on statements in a larger section to that section", func() {
+			expectKept(
+				"package a\n\n// regular comment\nfunc g() {\n\tx() // untested section\n\ty()\n}\n\nfunc h() {\n\tz()\n}\n",
+				"a.go:4.10,6.5 1 0\na.go:9.10,10.5 1 0\n",
+				[]string{"9.10,10.5"},
+			)
+		})
+
+		It("scopes markers to func literals", func() {
+			expectKept(
+				"package a\n\nfunc d() {\n\tgo func() { // untested section\n\t\tx()\n\t}()\n\ty()\n}\n",
+				"a.go:3.10,4.12 1 1\na.go:4.13,6.3 1 0\na.go:7.2,7.5 1 0\n",
+				[]string{"7.2,7.5"},
+			)
+		})
+
+		It("scopes markers at the end of a statement to the statement", func() {
+			expectKept(
+				"package a\n\nfunc e() {\n\tgo func() {\n\t\tx()\n\t}() // untested section\n\ty()\n}\n",
+				"a.go:3.10,4.12 1 1\na.go:4.13,6.3 1 0\na.go:7.2,7.5 1 0\n",
+				[]string{"7.2,7.5"},
+			)
+		})
+
+		It("ignores markers that are not next to code", func() {
+			expectKept(
+				"package a\n\nfunc f() {\n\tx()\n\n\t// untested section\n\n\ty()\n}\n",
+				"a.go:3.10,8.5 1 0\n",
+				[]string{"3.10,8.5"},
+			)
+		})
+
+		It("ignores functions with a directive, including closures", func() {
+			expectKept(
+				"package a\n\n// Handle does things\n//\n//testcov:ignore network failures\nfunc Handle(\n\tx int,\n) {\n\tgo func() {\n\t\tx()\n\t}()\n}\n\nfunc other() {\n\ty()\n}\n",
+				"a.go:8.3,9.12 1 0\na.go:9.12,11.3 1 0\na.go:14.14,15.5 1 0\n",
+				[]string{"14.14,15.5"},
+			)
+		})
+
+		It("ignores functions with a marker in their doc comment", func() {
+			expectKept(
+				"package a\n\n// Handle does things\n//\n// untested section\n//\n// more docs\nfunc (s *Server) Handle(\n\tx int,\n) {\n\ty()\n}\n\n// other does other things\nfunc other() {\n\ty()\n}\n",
+				"a.go:10.3,11.5 1 0\na.go:15.14,16.5 1 0\n",
+				[]string{"15.14,16.5"},
+			)
+		})
+
+		It("does not ignore functions with directive-like comments", func() {
+			expectKept(
+				"package a\n\n//testcov:ignored\nfunc a() {\n\ty()\n}\n",
+				"a.go:4.10,5.5 1 0\n",
+				[]string{"4.10,5.5"},
+			)
+		})
+
+		It("falls back to lines when the file does not parse", func() {
+			expectKept(
+				"if x { // untested section\n\ty()\n}\nz()\n",
+				"a.go:1.6,3.2 1 0\na.go:4.1,4.4 1 0\n",
+				[]string{"4.1,4.4"},
+			)
+		})
+	})
+})
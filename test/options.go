@@ -0,0 +1 @@
+../options.go
\ No newline at end of file
@@ -0,0 +1,171 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("options", func() {
+	Describe("parseOptions", func() {
+		It("passes on unknown arguments", func() {
+			options, remaining, err := parseOptions([]string{"-v", "./..."}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options).To(Equal(Options{format: formatText, unit: unitSections, percentOf: unitStatements}))
+			Expect(remaining).To(Equal([]string{"-v", "./..."}))
+		})
+
+		It("parses diff with ref", func() {
+			options, remaining, err := parseOptions([]string{"--diff=origin/main", "./..."}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.diff).To(Equal("origin/main"))
+			Expect(remaining).To(Equal([]string{"./..."}))
+		})
+
+		It("parses diff without ref", func() {
+			options, remaining, err := parseOptions([]string{"./...", "--diff"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.diff).To(Equal("HEAD"))
+			Expect(remaining).To(Equal([]string{"./..."}))
+		})
+
+		It("parses format and report", func() {
+			options, remaining, err := parseOptions([]string{"--format=json", "--report=out.json", "./..."}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.format).To(Equal(formatJSON))
+			Expect(options.report).To(Equal("out.json"))
+			Expect(remaining).To(Equal([]string{"./..."}))
+		})
+
+		It("parses verbose", func() {
+			options, _, err := parseOptions([]string{"--verbose"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.verbose).To(BeTrue())
+		})
+
+		It("parses no-color", func() {
+			options, _, err := parseOptions([]string{"--no-color"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.noColor).To(BeTrue())
+		})
+
+		It("parses strict and list-ignores", func() {
+			options, _, err := parseOptions([]string{"--strict", "--list-ignores"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.strict).To(BeTrue())
+			Expect(options.listIgnores).To(BeTrue())
+		})
+
+		It("parses update-baseline", func() {
+			options, _, err := parseOptions([]string{"--update-baseline"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.updateBaseline).To(BeTrue())
+		})
+
+		It("parses fix", func() {
+			options, _, err := parseOptions([]string{"--fix"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.fix).To(BeTrue())
+		})
+
+		It("parses unit", func() {
+			options, _, err := parseOptions([]string{"--unit=statements"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.unit).To(Equal(unitStatements))
+		})
+
+		It("fails on unknown unit", func() {
+			_, _, err := parseOptions([]string{"--unit=lines"}, Config{})
+			Expect(err).To(MatchError("unknown --unit \"lines\", use one of sections, statements"))
+		})
+
+		It("parses percent-of", func() {
+			options, _, err := parseOptions([]string{"--percent-of=lines"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.percentOf).To(Equal(unitLines))
+		})
+
+		It("fails on unknown percent-of", func() {
+			_, _, err := parseOptions([]string{"--percent-of=files"}, Config{})
+			Expect(err).To(MatchError("unknown --percent-of \"files\", use one of statements, lines"))
+		})
+
+		It("parses profiles with and without =", func() {
+			options, remaining, err := parseOptions([]string{"--profile", "a.out", "--profile=b.out", "c.out"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.profiles).To(Equal([]string{"a.out", "b.out"}))
+			Expect(remaining).To(Equal([]string{"c.out"}))
+		})
+
+		It("parses covdirs", func() {
+			options, _, err := parseOptions([]string{"--covdir", "e2e", "--covdir=cli"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.covDirs).To(Equal([]string{"e2e", "cli"}))
+		})
+
+		It("fails on profile without value", func() {
+			_, _, err := parseOptions([]string{"--profile"}, Config{})
+			Expect(err).To(MatchError("--profile needs a path"))
+		})
+
+		It("parses runner-cmd", func() {
+			options, _, err := parseOptions([]string{"--runner-cmd=richgo test {coverprofile}"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.runnerCmd).To(Equal("richgo test {coverprofile}"))
+		})
+
+		It("parses min-total with and without percent", func() {
+			options, _, err := parseOptions([]string{"--min-total=85%"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.minTotal).To(Equal(85))
+
+			options, _, err = parseOptions([]string{"--min-total=85"}, Config{})
+			Expect(err).To(BeNil())
+			Expect(options.minTotal).To(Equal(85))
+		})
+
+		It("fails on invalid min-total", func() {
+			_, _, err := parseOptions([]string{"--min-total=lots"}, Config{})
+			Expect(err).To(MatchError("--min-total needs a percentage like 85%, got \"lots\""))
+		})
+
+		It("uses format from config as default", func() {
+			options, _, err := parseOptions([]string{}, Config{format: formatJSON})
+			Expect(err).To(BeNil())
+			Expect(options.format).To(Equal(formatJSON))
+		})
+
+		It("fails on unknown format", func() {
+			_, _, err := parseOptions([]string{"--format=xml"}, Config{})
+			Expect(err).To(MatchError(`unknown --format "xml", use one of text, json, github, sarif`))
+		})
+	})
+
+	Describe("parseGoTestFlags", func() {
+		It("finds nothing when not given", func() {
+			Expect(parseGoTestFlags([]string{"-v", "./..."})).To(Equal(GoTestFlags{}))
+		})
+
+		It("finds flags with separate values", func() {
+			Expect(parseGoTestFlags([]string{"-cover", "-coverprofile", "a.out", "-covermode", "atomic", "-coverpkg", "./...", "-o", "bin", "-outputdir", "out"})).To(Equal(GoTestFlags{
+				cover:        true,
+				coverProfile: "a.out",
+				outputDir:    "out",
+			}))
+		})
+
+		It("finds flags with inline values and double dashes", func() {
+			Expect(parseGoTestFlags([]string{"--cover=false", "--coverprofile=a.out", "-covermode=set", "--output-dir=out"})).To(Equal(GoTestFlags{
+				coverProfile: "a.out",
+				outputDir:    "out",
+			}))
+		})
+
+		It("ignores flags passed to the test binary", func() {
+			Expect(parseGoTestFlags([]string{"./...", "-args", "-coverprofile", "a.out"})).To(Equal(GoTestFlags{}))
+		})
+
+		It("ignores a flag without value at the end", func() {
+			Expect(parseGoTestFlags([]string{"-coverprofile"})).To(Equal(GoTestFlags{}))
+		})
+	})
+})
@@ -0,0 +1 @@
+../profile.go
\ No newline at end of file
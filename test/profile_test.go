@@ -0,0 +1,80 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("profile", func() {
+	Describe("mergeProfiles", func() {
+		It("sums call counts of identical blocks in count mode", func() {
+			inTempDir(func() {
+				writeFile("a.out", "mode: count\nfoo.go:1.2,1.3 1 2\nfoo.go:2.2,2.3 1 0\n")
+				writeFile("b.out", "mode: count\nfoo.go:1.2,1.3 1 3\nbar.go:1.2,1.3 2 0\n")
+				merged, err := mergeProfiles([]string{"a.out", "b.out"})
+				Expect(err).To(BeNil())
+				Expect(merged).To(Equal([]Section{
+					{"foo.go", 1, 2, 1, 3, 100002, 1, 5},
+					{"foo.go", 2, 2, 2, 3, 200002, 1, 0},
+					{"bar.go", 1, 2, 1, 3, 100002, 2, 0},
+				}))
+			})
+		})
+
+		It("records if a block ran in set mode", func() {
+			inTempDir(func() {
+				writeFile("a.out", "mode: set\nfoo.go:1.2,1.3 1 1\nfoo.go:2.2,2.3 1 0\n")
+				writeFile("b.out", "mode: set\nfoo.go:1.2,1.3 1 1\nfoo.go:2.2,2.3 1 1\n")
+				merged, err := mergeProfiles([]string{"a.out", "b.out"})
+				Expect(err).To(BeNil())
+				Expect(merged).To(Equal([]Section{
+					{"foo.go", 1, 2, 1, 3, 100002, 1, 1},
+					{"foo.go", 2, 2, 2, 3, 200002, 1, 1},
+				}))
+			})
+		})
+
+		It("skips empty profiles", func() {
+			inTempDir(func() {
+				writeFile("a.out", "")
+				writeFile("b.out", "mode: set\nfoo.go:1.2,1.3 1 0\n")
+				merged, err := mergeProfiles([]string{"a.out", "b.out"})
+				Expect(err).To(BeNil())
+				Expect(merged).To(Equal([]Section{{"foo.go", 1, 2, 1, 3, 100002, 1, 0}}))
+			})
+		})
+
+		It("records if a block ran when set is mixed with atomic", func() {
+			inTempDir(func() {
+				writeFile("a.out", "mode: atomic\nfoo.go:1.2,1.3 1 3\nfoo.go:2.2,2.3 1 0\n")
+				writeFile("b.out", "mode: set\nfoo.go:1.2,1.3 1 1\nfoo.go:2.2,2.3 1 1\n")
+				writeFile("c.out", "mode: count\nfoo.go:1.2,1.3 1 2\n")
+				merged, err := mergeProfiles([]string{"a.out", "b.out", "c.out"})
+				Expect(err).To(BeNil())
+				Expect(merged).To(Equal([]Section{
+					{"foo.go", 1, 2, 1, 3, 100002, 1, 1},
+					{"foo.go", 2, 2, 2, 3, 200002, 1, 1},
+				}))
+			})
+		})
+
+		It("sums call counts when count is mixed with atomic", func() {
+			inTempDir(func() {
+				writeFile("a.out", "mode: count\nfoo.go:1.2,1.3 1 2\n")
+				writeFile("b.out", "mode: atomic\nfoo.go:1.2,1.3 1 3\n")
+				merged, err := mergeProfiles([]string{"a.out", "b.out"})
+				Expect(err).To(BeNil())
+				Expect(merged).To(Equal([]Section{{"foo.go", 1, 2, 1, 3, 100002, 1, 5}}))
+			})
+		})
+
+		It("fails on modes that cannot be merged", func() {
+			inTempDir(func() {
+				writeFile("a.out", "mode: set\n")
+				writeFile("b.out", "mode: unknown\n")
+				_, err := mergeProfiles([]string{"a.out", "b.out"})
+				Expect(err).To(MatchError("b.out has mode unknown but a.out has mode set, cannot merge"))
+			})
+		})
+	})
+})
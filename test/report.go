@@ -0,0 +1 @@
+../report.go
\ No newline at end of file
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("report", func() {
+	Describe("escapeGitHubData", func() {
+		It("escapes newlines and percent", func() {
+			Expect(escapeGitHubData("50%\nof\r")).To(Equal("50%25%0Aof%0D"))
+		})
+	})
+
+	Describe("repositoryPath", func() {
+		It("keeps paths without root", func() {
+			Expect(Report{}.repositoryPath("foo.go")).To(Equal("foo.go"))
+		})
+
+		It("makes paths relative to the root", func() {
+			inTempDir(func() {
+				root := filepath.Dir(mustGetwd())
+				Expect(Report{root: root}.repositoryPath("foo.go")).To(Equal(joinPath(filepath.Base(mustGetwd()), "foo.go")))
+				Expect(Report{root: root}.repositoryPath(joinPath(mustGetwd(), "foo.go"))).To(Equal(joinPath(filepath.Base(mustGetwd()), "foo.go")))
+			})
+		})
+
+		It("keeps paths outside of the root", func() {
+			Expect(Report{root: "/repo"}.repositoryPath("/gopath/src/foo.go")).To(Equal("/gopath/src/foo.go"))
+		})
+
+		It("keeps paths that cannot be made relative", func() {
+			Expect(Report{root: "repo"}.repositoryPath("/foo.go")).To(Equal("/foo.go"))
+		})
+	})
+
+	Describe("escapeGitHubProperty", func() {
+		It("escapes separators", func() {
+			Expect(escapeGitHubProperty("c:/foo,bar%")).To(Equal("c%3A/foo%2Cbar%25"))
+		})
+	})
+})
@@ -0,0 +1 @@
+../runner.go
\ No newline at end of file
@@ -0,0 +1,36 @@
+package main
+
+import (
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("runner", func() {
+	Describe("newRunner", func() {
+		table.DescribeTable("builds the command",
+			func(argv []string, runnerCmd string, expected []string) {
+				runner, err := newRunner(argv, runnerCmd)
+				Expect(err).To(BeNil())
+				Expect(runner.command([]string{"-race"}, []string{"-coverprofile", "c.out"})).To(Equal(expected))
+			},
+			table.Entry("go test", []string{"./..."}, "", []string{"go", "test", "-race", "./...", "-coverprofile", "c.out"}),
+			table.Entry("ginkgo v1", []string{"ginkgo", "-p", "./a", "./b"}, "", []string{"ginkgo", "-race", "-coverprofile", "c.out", "-p", "./a", "./b"}),
+			table.Entry("ginkgo v2", []string{"bin/ginkgo", "run", "-r"}, "", []string{"bin/ginkgo", "run", "-race", "-coverprofile", "c.out", "-r"}),
+			table.Entry("gotestsum", []string{"gotestsum", "--format", "dots", "--", "./..."}, "", []string{"gotestsum", "--format", "dots", "--", "-race", "./...", "-coverprofile", "c.out"}),
+			table.Entry("gotestsum without go test flags", []string{"gotestsum", "--format", "dots"}, "", []string{"gotestsum", "--format", "dots", "--", "-race", "-coverprofile", "c.out"}),
+			table.Entry("richgo", []string{"richgo", "test", "./..."}, "", []string{"richgo", "test", "-race", "./...", "-coverprofile", "c.out"}),
+			table.Entry("custom", []string{"./..."}, "bazel-go test {coverprofile} -v", []string{"bazel-go", "test", "-race", "-coverprofile", "c.out", "-v", "./..."}),
+		)
+
+		It("marks ginkgo as needing -cover", func() {
+			runner, _ := newRunner([]string{"ginkgo"}, "")
+			Expect(runner.needsCover).To(BeTrue())
+		})
+
+		It("fails on custom command without placeholder", func() {
+			_, err := newRunner([]string{}, "richgo test")
+			Expect(err).To(MatchError("--runner-cmd needs {coverprofile} where the coverage flags go"))
+		})
+	})
+})
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("sarif", func() {
+	Describe("writeSarif", func() {
+		writeAndParse := func(report Report) (log sarifLog) {
+			var out bytes.Buffer
+			noError(writeSarif(&out, report))
+			noError(json.Unmarshal(out.Bytes(), &log))
+			return
+		}
+
+		It("describes the tool", func() {
+			log := writeAndParse(Report{Files: []FileReport{}})
+			Expect(log.Version).To(Equal("2.1.0"))
+			Expect(log.Runs).To(HaveLen(1))
+			Expect(log.Runs[0].Tool.Driver.Name).To(Equal("go-testcov"))
+			Expect(log.Runs[0].Tool.Driver.Version).To(Equal(version))
+			Expect(log.Runs[0].Results).To(Equal([]sarifResult{}))
+		})
+
+		It("reports untested sections, decrements and warnings", func() {
+			log := writeAndParse(Report{Files: []FileReport{
+				{
+					ReadPath: "foo.go",
+					Budget:   Budget{Status: statusFail, Unit: unitSections},
+					Untested: []Section{{"foo.go", 2, 3, 4, 5, 200003, 1, 0}},
+					Warnings: []Warning{
+						{1, "has `// untested section` but is tested", ruleTestedIgnore},
+						{2, "has an ignore marker that expired on 2020-01-01", ruleExpiredIgnore},
+					},
+				},
+				{
+					ReadPath: "/gopath/src/bar.go",
+					Budget: Budget{
+						Status:             statusDecrement,
+						Unit:               unitSections,
+						ConfiguredUntested: 2,
+						ConfiguredIn:       "/gopath/src/bar.go",
+						ConfiguredAtLine:   3,
+					},
+				},
+			}})
+			Expect(log.Runs[0].Results).To(Equal([]sarifResult{
+				{
+					RuleID:    ruleTestedIgnore,
+					Level:     "warning",
+					Message:   sarifMessage{"has `// untested section` but is tested"},
+					Locations: sarifLocations("foo.go", sarifRegion{StartLine: 1}),
+				},
+				{
+					RuleID:    ruleExpiredIgnore,
+					Level:     "warning",
+					Message:   sarifMessage{"has an ignore marker that expired on 2020-01-01"},
+					Locations: sarifLocations("foo.go", sarifRegion{StartLine: 2}),
+				},
+				{
+					RuleID:    ruleUntested,
+					Level:     "error",
+					Message:   sarifMessage{"new untested section introduced (0 current vs 0 configured)"},
+					Locations: sarifLocations("foo.go", sarifRegion{2, 3, 4, 5}),
+				},
+				{
+					RuleID:    ruleDecrement,
+					Level:     "note",
+					Message:   sarifMessage{"less untested sections (0 current vs 2 configured), decrement configured untested?"},
+					Locations: sarifLocations("file:///gopath/src/bar.go", sarifRegion{StartLine: 3}),
+				},
+			}))
+		})
+	})
+})
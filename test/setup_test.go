@@ -8,6 +8,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestAwesome(t *testing.T) {
@@ -27,6 +28,12 @@ func writeFile(path string, content string) {
 	noError(err)
 }
 
+func readFile(path string) (content string) {
+	data, err := ioutil.ReadFile(path)
+	noError(err)
+	return string(data)
+}
+
 func withTempFile(content string, fn func(*os.File)) {
 	file, err := ioutil.TempFile("", "go-testcov")
 	noError(err)
@@ -74,6 +81,12 @@ func inTempDir(fn func()) {
 	})
 }
 
+func mustGetwd() string {
+	wd, err := os.Getwd()
+	noError(err)
+	return wd
+}
+
 func chDir(dir string, fn func()) {
 	old, err := os.Getwd()
 	noError(err)
@@ -170,3 +183,12 @@ func withOsArgs(args []string, fn func()) {
 	fn()
 
 }
+
+// pretend it is the given day, so expiring markers do not start failing tests
+func withNow(date string, fn func()) {
+	parsed, err := time.Parse(time.DateOnly, date)
+	noError(err)
+	now = func() time.Time { return parsed }
+	defer func() { now = time.Now }()
+	fn()
+}
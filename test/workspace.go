@@ -0,0 +1 @@
+../workspace.go
\ No newline at end of file
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("workspace", func() {
+	Describe("findWorkspace", func() {
+		It("finds nothing outside of modules", func() {
+			inTempDir(func() {
+				Expect(findWorkspace(mustGetwd())).To(Equal(Workspace{}))
+			})
+		})
+
+		It("finds the module in a parent directory", func() {
+			inTempDir(func() {
+				wd := mustGetwd()
+				writeFile("go.mod", "module \"example.com/x\"\n\ngo 1.22\n")
+				os.Mkdir("sub", 0700)
+				Expect(findWorkspace(joinPath(wd, "sub"))).To(Equal(Workspace{wd, []Module{{"example.com/x", wd}}}))
+			})
+		})
+
+		It("finds all modules of a go.work", func() {
+			inTempDir(func() {
+				wd := mustGetwd()
+				writeFile("go.work", "go 1.22\n\nuse ./a\n\nuse (\n\t./b // comment\n\t\"./missing\"\n)\n")
+				os.Mkdir("a", 0700)
+				writeFile(joinPath("a", "go.mod"), "module gopkg.in/yaml.v3\n")
+				os.Mkdir("b", 0700)
+				writeFile(joinPath("b", "go.mod"), "module example.com/b\n")
+				Expect(findWorkspace(wd)).To(Equal(Workspace{wd, []Module{
+					{"gopkg.in/yaml.v3", joinPath(wd, "a")},
+					{"example.com/b", joinPath(wd, "b")},
+				}}))
+			})
+		})
+
+		It("ignores go.work when GOWORK is off", func() {
+			inTempDir(func() {
+				wd := mustGetwd()
+				writeFile("go.work", "use ./a\n")
+				writeFile("go.mod", "module example.com/x\n")
+				withEnv("GOWORK", "off", func() {
+					Expect(findWorkspace(wd)).To(Equal(Workspace{wd, []Module{{"example.com/x", wd}}}))
+				})
+			})
+		})
+
+		It("uses the go.work from GOWORK", func() {
+			inTempDir(func() {
+				wd := mustGetwd()
+				os.Mkdir("work", 0700)
+				writeFile(joinPath("work", "go.work"), "use "+wd+"\n")
+				writeFile("go.mod", "module example.com/x\n")
+				withEnv("GOWORK", joinPath(wd, "work", "go.work"), func() {
+					Expect(findWorkspace(wd)).To(Equal(Workspace{joinPath(wd, "work"), []Module{{"example.com/x", wd}}}))
+				})
+			})
+		})
+
+		It("ignores go.mod without module", func() {
+			inTempDir(func() {
+				wd := mustGetwd()
+				writeFile("go.mod", "go 1.22\n")
+				Expect(findWorkspace(wd)).To(Equal(Workspace{root: wd}))
+			})
+		})
+
+		It("fails when GOWORK is not absolute like go does", func() {
+			withEnv("GOWORK", "go.work", func() {
+				_, err := findWorkspace(mustGetwd())
+				Expect(err).To(MatchError(ConfigError{errors.New("GOWORK must be an absolute path but got go.work")}))
+			})
+		})
+
+		It("fails when the go.work cannot be read", func() {
+			inTempDir(func() {
+				wd := mustGetwd()
+				withEnv("GOWORK", joinPath(wd, "go.work"), func() {
+					_, err := findWorkspace(wd)
+					Expect(errors.As(err, &ConfigError{})).To(BeTrue())
+					Expect(err.Error()).To(HaveSuffix("go.work: no such file or directory"))
+				})
+			})
+		})
+
+		It("fails when a go.mod of the go.work cannot be read", func() {
+			inTempDir(func() {
+				wd := mustGetwd()
+				writeFile("go.work", "use ./a\n")
+				os.MkdirAll(joinPath("a", "go.mod"), 0700)
+				_, err := findWorkspace(wd)
+				Expect(errors.As(err, &ConfigError{})).To(BeTrue())
+				Expect(err.Error()).To(HaveSuffix("is a directory"))
+			})
+		})
+	})
+
+	Describe("findRepositoryRoot", func() {
+		It("uses GITHUB_WORKSPACE", func() {
+			withEnv("GITHUB_WORKSPACE", "/repo", func() {
+				Expect(findRepositoryRoot("/repo/sub")).To(Equal("/repo"))
+			})
+		})
+
+		It("uses the git root", func() {
+			withoutEnv("GITHUB_WORKSPACE", func() {
+				withFakeExecutable("git", "echo /repo", func() {
+					Expect(findRepositoryRoot("/repo/sub")).To(Equal("/repo"))
+				})
+			})
+		})
+
+		It("uses the working directory outside of git", func() {
+			withoutEnv("GITHUB_WORKSPACE", func() {
+				withFakeExecutable("git", "exit 128", func() {
+					Expect(findRepositoryRoot("/repo/sub")).To(Equal("/repo/sub"))
+				})
+			})
+		})
+	})
+
+	Describe("displayPath", func() {
+		It("is relative to the root", func() {
+			Expect(Workspace{root: "/work"}.displayPath("/work/a/b.go")).To(Equal(joinPath("a", "b.go")))
+		})
+
+		It("keeps paths that cannot be made relative", func() {
+			Expect(Workspace{root: "work"}.displayPath("/a/b.go")).To(Equal("/a/b.go"))
+		})
+	})
+
+	Describe("resolve", func() {
+		It("uses the longest module that has the file", func() {
+			inTempDir(func() {
+				wd := mustGetwd()
+				os.MkdirAll(joinPath("x", "sub"), 0700)
+				writeFile(joinPath("x", "sub", "a.go"), "")
+				writeFile(joinPath("x", "b.go"), "")
+				os.Mkdir("sub", 0700)
+				writeFile(joinPath("sub", "a.go"), "")
+				workspace := Workspace{wd, []Module{{"example.com/x/sub", joinPath(wd, "sub")}, {"example.com/x", joinPath(wd, "x")}}}
+
+				path, found := workspace.resolve("example.com/x/sub/a.go")
+				Expect(found).To(BeTrue())
+				Expect(path).To(Equal(joinPath(wd, "sub", "a.go")))
+
+				path, found = workspace.resolve("example.com/x/b.go")
+				Expect(found).To(BeTrue())
+				Expect(path).To(Equal(joinPath(wd, "x", "b.go")))
+
+				_, found = workspace.resolve("example.com/xy/b.go")
+				Expect(found).To(BeFalse())
+			})
+		})
+	})
+})
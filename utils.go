@@ -2,11 +2,10 @@ package main
 
 import (
 	"fmt"
-	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 	"syscall"
 )
@@ -51,13 +50,6 @@ func runCommand(args ...string) (exitCode int) {
 	return
 }
 
-// read a file into a string
-func readFile(path string) (content string) {
-	data, err := ioutil.ReadFile(path)
-	check(err)
-	return string(data)
-}
-
 // iterate a map by it's sorted keys
 func iterateBySortedKey(data map[string][]Section, fn func(string, []Section)) {
 	keys := make([]string, len(data))
@@ -72,14 +64,19 @@ func iterateBySortedKey(data map[string][]Section, fn func(string, []Section)) {
 	}
 }
 
-func joinPath(parts ...string) string {
-	return strings.Join(parts, string(os.PathSeparator))
+// shorten absolute paths inside the working directory, everything else stays as is
+func relativeToWorkingDirectory(path string, workingDirectory string) string {
+	if filepath.IsAbs(path) {
+		relative, err := filepath.Rel(workingDirectory, path)
+		if err == nil && !strings.HasPrefix(relative, "..") {
+			return relative
+		}
+	}
+	return path
 }
 
-func stringToInt(string string) int {
-	converted, err := strconv.Atoi(string)
-	check(err)
-	return converted
+func joinPath(parts ...string) string {
+	return strings.Join(parts, string(os.PathSeparator))
 }
 
 func lineNumberOfMatch(content string) int {
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var moduleDirective = regexp.MustCompile(`(?m)^module\s+"?([^"\s]+)"?`)
+var useDirective = regexp.MustCompile(`(?m)^use\s+(?:\(([^)]*)\)|(\S+))`)
+
+// Module maps an import path prefix to the directory its files are in
+type Module struct {
+	path string
+	dir  string // absolute
+}
+
+// Workspace are the modules of the go.work or go.mod that tests are run in
+type Workspace struct {
+	root    string // directory of go.work or go.mod, "" when there is none
+	modules []Module
+}
+
+// find the go.work or go.mod that go would use in the working directory
+// fails like go does when the go.work cannot be read, everything that goes wrong here is for the user to fix
+func findWorkspace(workingDirectory string) (workspace Workspace, err error) {
+	if work := findGoWork(workingDirectory); work != "" {
+		if !filepath.IsAbs(work) {
+			return Workspace{}, ConfigError{fmt.Errorf("GOWORK must be an absolute path but got %v", work)}
+		}
+		content, err := os.ReadFile(work)
+		if err != nil {
+			return Workspace{}, ConfigError{err}
+		}
+		workspace.root = filepath.Dir(work)
+		for _, dir := range parseUses(string(content)) {
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(workspace.root, dir)
+			}
+			if err := workspace.addModule(dir); err != nil {
+				return Workspace{}, err
+			}
+		}
+		return workspace, nil
+	}
+
+	if mod := findUp(workingDirectory, "go.mod"); mod != "" {
+		workspace.root = filepath.Dir(mod)
+		err = workspace.addModule(workspace.root)
+	}
+	return
+}
+
+// root of the repository that annotation paths are relative to, GITHUB_WORKSPACE on github actions, the git root otherwise
+func findRepositoryRoot(workingDirectory string) string {
+	if root := os.Getenv("GITHUB_WORKSPACE"); root != "" {
+		return root
+	}
+	if output, err := runGit("rev-parse", "--show-toplevel"); err == nil {
+		return strings.TrimSpace(output)
+	}
+	return workingDirectory
+}
+
+// go.work set with GOWORK, or in the working directory or its parents
+func findGoWork(workingDirectory string) string {
+	switch work := os.Getenv("GOWORK"); work {
+	case "off":
+		return ""
+	case "":
+		return findUp(workingDirectory, "go.work")
+	default:
+		return work
+	}
+}
+
+// path of the file in the directory or its parents, "" when not found
+func findUp(dir string, name string) string {
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// directories listed with `use ./dir` or in a `use (...)` block
+func parseUses(content string) (dirs []string) {
+	for _, match := range useDirective.FindAllStringSubmatch(content, -1) {
+		for _, line := range strings.Split(match[1]+"\n"+match[2], "\n") {
+			line, _, _ = strings.Cut(line, "//")
+			if dir := strings.Trim(strings.TrimSpace(line), `"`); dir != "" {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return
+}
+
+func (w *Workspace) addModule(dir string) error {
+	path := filepath.Join(dir, "go.mod")
+	if _, err := os.Stat(path); err != nil {
+		return nil // go would fail on this, so there will not be coverage for it either
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ConfigError{err}
+	}
+	if match := moduleDirective.FindStringSubmatch(string(content)); match != nil {
+		w.modules = append(w.modules, Module{match[1], dir})
+	}
+	return nil
+}
+
+// absolute path of the file for an import path from a coverage profile, using the longest matching module
+func (w Workspace) resolve(importPath string) (path string, found bool) {
+	longest := -1
+	for _, module := range w.modules {
+		rest, ok := strings.CutPrefix(importPath, module.path+"/")
+		if !ok || len(module.path) <= longest {
+			continue
+		}
+		candidate := filepath.Join(module.dir, filepath.FromSlash(rest))
+		if _, err := os.Stat(candidate); err == nil {
+			path, found, longest = candidate, true, len(module.path)
+		}
+	}
+	return
+}
+
+// path to show users, relative to the go.work or go.mod so it is the same no matter where tests are run
+// paths that cannot be made relative are shown as they are
+func (w Workspace) displayPath(path string) string {
+	if relative, err := filepath.Rel(w.root, path); err == nil {
+		return relative
+	}
+	return path
+}